@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"os"
@@ -27,6 +28,18 @@ func main() {
 	//mux.HandleFunc("/interrupcion", utils.Interruption)
 	mux.HandleFunc("/receiveDataFromMemory", utils.RecieveDataFromMemory)
 	mux.HandleFunc("/interrupcion", utils.RecieveInterruption)
-	http.ListenAndServe(":"+strconv.Itoa(puerto), mux)
+	mux.HandleFunc("/reiniciar", utils.Reiniciar)
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(puerto))
+	if err != nil {
+		log.Fatalf("No se pudo escuchar en el puerto %d: %v", puerto, err)
+	}
+
+	// Recien cuando la CPU escucha se registra, porque el Kernel le puede despachar un hilo enseguida
+	if globals.ClientConfig.Ip != "" {
+		go utils.RegistrarEnKernel(globals.ClientConfig.Ip, puerto)
+	}
+
+	http.Serve(listener, mux)
 
 }
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sisoputnfrba/tp-golang/cpu/globals"
 )
@@ -27,6 +30,18 @@ var dataFromMemory uint32
 var flagSegmentationFault bool
 var syscallEnviada bool = false
 
+// Si el Kernel que despacho el hilo se cae la CPU abandona el hilo y se vuelve
+// a registrar. Un Kernel restaurado la reinicia con POST /reiniciar.
+const intervaloReintentoRegistro = time.Second
+
+var mutexEjecucion sync.Mutex
+var despachoActual = context.Background()
+var reinicioPendiente atomic.Bool
+var reregistrando atomic.Bool
+
+// Desde esta direccion logica se ven las regiones de memoria compartida adjuntadas
+const InicioMemoriaCompartida uint32 = 0x80000000
+
 // DEFINICION DE TIPOS
 type hiloAnterior struct {
 	Pid int
@@ -43,9 +58,25 @@ type DataRead struct {
 }
 
 type MutexRequest struct {
-	Pid   int    `json:"pid"`
-	Tid   int    `json:"tid"`
-	Mutex string `json:"mutex"`
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Mutex    string `json:"mutex"`
+	Registro string `json:"registro,omitempty"` // registro donde el kernel deja el resultado de TRYLOCK y TIMEDLOCK
+	Tiempo   int    `json:"tiempo,omitempty"`   // ms de espera maxima de TIMEDLOCK
+}
+
+type SemaforoRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Semaforo string `json:"semaforo"`
+	Valor    int    `json:"valor"`
+}
+
+type CondicionRequest struct {
+	Pid       int    `json:"pid"`
+	Tid       int    `json:"tid"`
+	Condicion string `json:"condicion"`
+	Mutex     string `json:"mutex"`
 }
 
 type InstructionResponse struct {
@@ -74,10 +105,11 @@ type Interrupt struct {
 type MemoryRequest struct {
 	PID     int    `json:"pid"`
 	TID     int    `json:"tid,omitempty"`
-	Address uint32 `json:"address"`        //direccion de memoria a leer
-	Size    int    `json:"size,omitempty"` //tamaño de la memoria a leer
-	Data    []byte `json:"data,omitempty"` //datos a escribir o leer y los devuelvo
-	Port    int    `json:"port,omitempty"` //puerto
+	Address uint32 `json:"address"`          //direccion de memoria a leer
+	Size    int    `json:"size,omitempty"`   //tamaño de la memoria a leer
+	Data    []byte `json:"data,omitempty"`   //datos a escribir o leer y los devuelvo
+	Port    int    `json:"port,omitempty"`   //puerto
+	Region  string `json:"region,omitempty"` //region compartida, Address es el desplazamiento dentro de ella
 }
 
 type PCB struct {
@@ -101,8 +133,9 @@ type TCB struct {
 }
 
 type contextoEjecucion struct {
-	pcb PCB
-	tcb TCB
+	pcb         PCB
+	tcb         TCB
+	compartidas []RegionAdjunta
 }
 type DecodedInstruction struct {
 	instruction FuncInctruction
@@ -111,8 +144,16 @@ type DecodedInstruction struct {
 type FuncInctruction func(*contextoEjecucion, []string) error
 
 type BodyContexto struct {
-	Pcb PCB `json:"pcb"`
-	Tcb TCB `json:"tcb"`
+	Pcb         PCB             `json:"pcb"`
+	Tcb         TCB             `json:"tcb"`
+	Compartidas []RegionAdjunta `json:"compartidas,omitempty"`
+}
+
+// Region compartida vista por el proceso desde la direccion logica Base
+type RegionAdjunta struct {
+	Nombre  string `json:"nombre"`
+	Base    uint32 `json:"base"`
+	Tamanio uint32 `json:"tamanio"`
 }
 
 type ProcessCreateBody struct {
@@ -121,21 +162,67 @@ type ProcessCreateBody struct {
 	Priority string `json:"prioridad"`
 }
 type KernelExeReq struct {
-	Pid int `json:"pid"` // ver cuales son los keys usados en Kernel
-	Tid int `json:"tid"`
+	Pid    int             `json:"pid"` // ver cuales son los keys usados en Kernel
+	Tid    int             `json:"tid"`
+	Codigo uint32          `json:"codigo,omitempty"` // codigo de salida de THREAD_EXIT
+	Senal  *SenalEntregada `json:"senal,omitempty"`  // señal a atender al recibir el hilo
 }
-type IOReq struct {
-	Tiempo int `json:"tiempoIO"`
+
+type SenalEntregada struct {
+	Senal     int    `json:"senal"`
+	Manejador uint32 `json:"manejador"`
+}
+
+type MensajeBody struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Cola     string `json:"cola"`
+	Valor    uint32 `json:"valor,omitempty"`
+	Registro string `json:"registro,omitempty"`
+}
+
+type MemoriaCompartidaBody struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Nombre   string `json:"nombre"`
+	Tamanio  int    `json:"tamanio,omitempty"`
+	Registro string `json:"registro,omitempty"`
+}
+
+type DormirBody struct {
 	Pid    int `json:"pid"`
 	Tid    int `json:"tid"`
+	Tiempo int `json:"tiempo"`
+}
+
+type SenalBody struct {
+	Pid     int    `json:"pid"`
+	Tid     int    `json:"tid"`
+	Destino int    `json:"destino,omitempty"`
+	Senal   int    `json:"senal"`
+	Pc      uint32 `json:"pc,omitempty"`
+}
+type IOReq struct {
+	Tiempo      int    `json:"tiempoIO"`
+	Pid         int    `json:"pid"`
+	Tid         int    `json:"tid"`
+	Dispositivo string `json:"dispositivo,omitempty"`
 }
 
 type IniciarProcesoBody struct {
-	Path      string `json:"path"`
-	Size      int    `json:"size"`
-	Prioridad int    `json:"prioridad"`
-	PidActual int    `json:"pidActual"`
-	TidActual int    `json:"tidActual"`
+	Path       string                `json:"path"`
+	Size       int                   `json:"size"`
+	Prioridad  int                   `json:"prioridad"`
+	PidActual  int                   `json:"pidActual"`
+	TidActual  int                   `json:"tidActual"`
+	TiempoReal *ParametrosTiempoReal `json:"tiempoReal,omitempty"`
+}
+
+// Parametros opcionales de PROCESS_CREATE para un proceso de tiempo real, en ms
+type ParametrosTiempoReal struct {
+	Periodo  int `json:"periodo"`
+	Deadline int `json:"deadline"`
+	Computo  int `json:"computo"`
 }
 
 type CrearHiloBody struct {
@@ -143,10 +230,23 @@ type CrearHiloBody struct {
 	Path      string `json:"path"`
 	Prioridad int    `json:"prioridad"`
 }
+type RegistroCpuBody struct {
+	Ip     string `json:"ip"`
+	Puerto int    `json:"puerto"`
+}
+
+type EsperaProcesoBody struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Hijo     int    `json:"hijo"` // -1 para esperar a cualquier hijo
+	Registro string `json:"registro"`
+}
+
 type EfectoHiloBody struct {
-	Pid       int `json:"pid"`
-	TidActual int `json:"tidActual"`
-	TidCambio int `json:"tidAEjecutar"`
+	Pid       int    `json:"pid"`
+	TidActual int    `json:"tidActual"`
+	TidCambio int    `json:"tidAEjecutar"`
+	Registro  string `json:"registro,omitempty"`
 }
 
 //	INICIAR CONFIGURACION Y LOGGERS
@@ -197,11 +297,27 @@ func RecibirPIDyTID(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("ok"))
+
+	// El Kernel espera la respuesta hasta que la CPU deja de ejecutar el hilo,
+	// si se cae se cancela el contexto del pedido
+	mutexEjecucion.Lock()
+	defer mutexEjecucion.Unlock()
+	despachoActual = r.Context()
+
 	wg.Add(1)
 	contextoActual := GetContextoEjecucion(processAndThreadIDs.Pid, processAndThreadIDs.Tid)
 
+	if processAndThreadIDs.Senal != nil {
+		if err := AtenderSenal(&contextoActual, *processAndThreadIDs.Senal); err != nil {
+			log.Printf("Error al atender la señal %d: %v", processAndThreadIDs.Senal.Senal, err)
+		}
+	}
+
 	InstructionCycle(&contextoActual)
 
+	if despachoActual.Err() != nil {
+		volverARegistrarse()
+	}
 }
 func GetContextoEjecucion(pid int, tid int) (context contextoEjecucion) {
 	log.Printf("Busca el contexto de ejecucion")
@@ -245,6 +361,7 @@ func GetContextoEjecucion(pid int, tid int) (context contextoEjecucion) {
 	log.Printf("PCB : %d TID : %d - Solicitud Contexto de Ejecucion Exitosa", contexto.Pcb.Pid, contexto.Tcb.Tid)
 	contextoDeEjecucion.pcb = contexto.Pcb
 	contextoDeEjecucion.tcb = contexto.Tcb
+	contextoDeEjecucion.compartidas = contexto.Compartidas
 	return contextoDeEjecucion
 }
 
@@ -266,6 +383,11 @@ func InstructionCycle(contexto *contextoEjecucion) {
 	guardarPidyTid(contexto.pcb.Pid, contexto.tcb.Tid)
 
 	for {
+		if reinicioPendiente.Load() || despachoActual.Err() != nil {
+			log.Printf("## Se abandona el hilo PID: %d, TID: %d - El Kernel que lo despacho ya no lo espera", contexto.pcb.Pid, contexto.tcb.Tid)
+			break
+		}
+
 		log.Printf("Instrucción solicitada de PID: %d, TID: %d, PC: %d", contexto.pcb.Pid, contexto.tcb.Tid, contexto.tcb.PC)
 
 		// Fetch
@@ -317,6 +439,56 @@ func InstructionCycle(contexto *contextoEjecucion) {
 	}
 }
 
+func RegistrarEnKernel(ip string, puerto int) {
+	body, err := json.Marshal(RegistroCpuBody{
+		Ip:     ip,
+		Puerto: puerto,
+	})
+	if err != nil {
+		log.Printf("Error al codificar el registro de la CPU: %v", err)
+		return
+	}
+
+	// Se reintenta hasta que el Kernel responde, puede estar arrancando
+	for {
+		err = EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), "registrarCpu")
+		if err == nil {
+			break
+		}
+		log.Printf("Error al registrar la CPU en el Kernel, se reintenta: %v", err)
+		time.Sleep(intervaloReintentoRegistro)
+	}
+	log.Printf("## CPU registrada en el Kernel - IP: %s - Puerto: %d", ip, puerto)
+}
+
+// volverARegistrarse registra la CPU en el Kernel que vuelve despues de caerse,
+// porque si arranca desde un checkpoint no conoce a las CPUs que se registraron despues
+func volverARegistrarse() {
+	if ConfigsCpu.Ip == "" || !reregistrando.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		RegistrarEnKernel(ConfigsCpu.Ip, ConfigsCpu.Puerto)
+		reregistrando.Store(false)
+	}()
+}
+
+// Reiniciar abandona el hilo que se esta ejecutando y responde cuando la CPU ya
+// no ejecuta nada. Lo usa un Kernel restaurado antes de volver a despachar.
+func Reiniciar(w http.ResponseWriter, r *http.Request) {
+	reinicioPendiente.Store(true)
+	mutexEjecucion.Lock()
+	reinicioPendiente.Store(false)
+
+	mutexInterrupt.Lock()
+	nuevaInterrupcion = Interrupt{}
+	mutexInterrupt.Unlock()
+	mutexEjecucion.Unlock()
+
+	log.Printf("## CPU reiniciada por el Kernel")
+	w.WriteHeader(http.StatusOK)
+}
+
 func EnviarSegmentationFault(pid int, tid int) error {
 	kernelReq := KernelExeReq{
 		Pid: pid,
@@ -418,24 +590,43 @@ func Fetch(pid int, tid int, PC *uint32) ([]string, error) {
 
 func Decode(instructionLine []string) (DecodedInstruction, error) {
 	var SetInstructions map[string]FuncInctruction = map[string]FuncInctruction{
-		"SET":            Set,
-		"SUM":            Sumar,
-		"SUB":            Restar,
-		"JNZ":            JNZ,
-		"LOG":            Log,
-		"DUMP_MEMORY":    DumpMemory,
-		"IO":             IO,
-		"PROCESS_CREATE": CreateProcess,
-		"THREAD_CREATE":  CreateThead,
-		"THREAD_JOIN":    JoinThead,
-		"THREAD_CANCEL":  CancelThead,
-		"THREAD_EXIT":    ThreadExit,
-		"PROCESS_EXIT":   ProcessExit,
-		"READ_MEM":       Read_Memory,
-		"WRITE_MEM":      Write_Memory,
-		"MUTEX_CREATE":   MutexCreate,
-		"MUTEX_LOCK":     MutexLOCK,
-		"MUTEX_UNLOCK":   MutexUNLOCK,
+		"SET":             Set,
+		"SUM":             Sumar,
+		"SUB":             Restar,
+		"JNZ":             JNZ,
+		"LOG":             Log,
+		"DUMP_MEMORY":     DumpMemory,
+		"IO":              IO,
+		"PROCESS_CREATE":  CreateProcess,
+		"THREAD_CREATE":   CreateThead,
+		"THREAD_JOIN":     JoinThead,
+		"THREAD_CANCEL":   CancelThead,
+		"THREAD_EXIT":     ThreadExit,
+		"PROCESS_EXIT":    ProcessExit,
+		"PROCESS_WAIT":    ProcessWait,
+		"SIGNAL":          Signal,
+		"SIGNAL_HANDLER":  SignalHandler,
+		"SIGNAL_RETURN":   SignalReturn,
+		"MQ_SEND":         MqSend,
+		"MQ_RECEIVE":      MqReceive,
+		"SHM_CREATE":      ShmCreate,
+		"SHM_ATTACH":      ShmAttach,
+		"YIELD":           Yield,
+		"SLEEP":           Sleep,
+		"READ_MEM":        Read_Memory,
+		"WRITE_MEM":       Write_Memory,
+		"MUTEX_CREATE":    MutexCreate,
+		"MUTEX_LOCK":      MutexLOCK,
+		"MUTEX_UNLOCK":    MutexUNLOCK,
+		"MUTEX_TRYLOCK":   MutexTRYLOCK,
+		"MUTEX_TIMEDLOCK": MutexTIMEDLOCK,
+		"SEM_CREATE":      SemCreate,
+		"SEM_WAIT":        SemWait,
+		"SEM_POST":        SemPost,
+		"COND_CREATE":     CondCreate,
+		"COND_WAIT":       CondWait,
+		"COND_SIGNAL":     CondSignal,
+		"COND_BROADCAST":  CondBroadcast,
 	}
 
 	var instructionDecoded DecodedInstruction
@@ -515,7 +706,7 @@ func Read_Memory(context *contextoEjecucion, parameters []string) error {
 	}
 
 	// Traduce la dirección lógica a física.
-	physicalAddress, err := TranslateAdress(logicalAddress, context.pcb.Base, context.pcb.Limit)
+	physicalAddress, region, err := TraducirDireccion(context, logicalAddress)
 	if err != nil {
 		return err
 	}
@@ -525,6 +716,7 @@ func Read_Memory(context *contextoEjecucion, parameters []string) error {
 		Address: physicalAddress,
 		PID:     context.pcb.Pid,
 		TID:     context.tcb.Tid,
+		Region:  region,
 	}
 
 	body, err := json.Marshal(memReq)
@@ -611,7 +803,7 @@ func Write_Memory(context *contextoEjecucion, parameters []string) error {
 	}
 
 	// Traduce la dirección lógica a física.
-	physicalAddress, err := TranslateAdress(logicalAddress, context.pcb.Base, context.pcb.Limit)
+	physicalAddress, region, err := TraducirDireccion(context, logicalAddress)
 	if err != nil {
 		return err
 	}
@@ -622,6 +814,7 @@ func Write_Memory(context *contextoEjecucion, parameters []string) error {
 		Data:    PasarDeUintAByte(data),
 		PID:     context.pcb.Pid,
 		TID:     context.tcb.Tid,
+		Region:  region,
 	}
 
 	// Codifica la solicitud en formato JSON.
@@ -658,6 +851,30 @@ func TranslateAdress(direccionLogica uint32, base uint32, limite uint32) (uint32
 	return direccionFisica, nil
 }
 
+// TraducirDireccion traduce las direcciones desde InicioMemoriaCompartida a un
+// desplazamiento dentro de la region compartida adjuntada que las contiene. El
+// resto se traduce con la base y el limite de la particion del proceso.
+func TraducirDireccion(contexto *contextoEjecucion, direccionLogica uint32) (uint32, string, error) {
+	if direccionLogica < InicioMemoriaCompartida {
+		direccionFisica, err := TranslateAdress(direccionLogica, contexto.pcb.Base, contexto.pcb.Limit)
+		return direccionFisica, "", err
+	}
+
+	for _, region := range contexto.compartidas {
+		if direccionLogica < region.Base || direccionLogica-region.Base >= region.Tamanio {
+			continue
+		}
+		desplazamiento := direccionLogica - region.Base
+		if desplazamiento+4 > region.Tamanio {
+			break
+		}
+		return desplazamiento, region.Nombre, nil
+	}
+
+	flagSegmentationFault = true
+	return 0, "", fmt.Errorf("Segmentation Fault")
+}
+
 func Sumar(registrosCPU *contextoEjecucion, parameters []string) error {
 	registroDestino := parameters[0]
 	registroOrigen := parameters[1]
@@ -771,8 +988,14 @@ func DumpMemory(contexto *contextoEjecucion, parameters []string) error {
 	return nil
 }
 
+// IO <tiempo> o IO <dispositivo> <tiempo>
 func IO(contexto *contextoEjecucion, parameters []string) error {
+	var dispositivo string
 	tiempo := parameters[0]
+	if len(parameters) > 1 {
+		dispositivo = parameters[0]
+		tiempo = parameters[1]
+	}
 
 	err := ActualizarContextoDeEjecucion(contexto)
 	if err != nil {
@@ -786,9 +1009,10 @@ func IO(contexto *contextoEjecucion, parameters []string) error {
 	}
 
 	body, err := json.Marshal(IOReq{
-		Tiempo: tiempoReq,
-		Pid:    contexto.pcb.Pid,
-		Tid:    contexto.tcb.Tid,
+		Tiempo:      tiempoReq,
+		Pid:         contexto.pcb.Pid,
+		Tid:         contexto.tcb.Tid,
+		Dispositivo: dispositivo,
 	})
 	if err != nil {
 		log.Printf("Error al codificar el mernsaje")
@@ -824,12 +1048,25 @@ func CreateProcess(contexto *contextoEjecucion, parameters []string) error {
 		return fmt.Errorf("error al convertir prioridad TID: %v", err)
 	}
 
+	// PROCESS_CREATE <archivo> <tamaño> <prioridad> [<periodo> <deadline> <computo>]
+	var tiempoReal *ParametrosTiempoReal
+	if len(parameters) >= 6 {
+		periodo, errPeriodo := strconv.Atoi(parameters[3])
+		deadline, errDeadline := strconv.Atoi(parameters[4])
+		computo, errComputo := strconv.Atoi(parameters[5])
+		if errPeriodo != nil || errDeadline != nil || errComputo != nil {
+			return fmt.Errorf("error al convertir parametros de tiempo real: %v", parameters[3:6])
+		}
+		tiempoReal = &ParametrosTiempoReal{Periodo: periodo, Deadline: deadline, Computo: computo}
+	}
+
 	body, err := json.Marshal(IniciarProcesoBody{
-		Path:      archivoInstruct,
-		Size:      tamArchReal,
-		Prioridad: priorityReal,
-		PidActual: contexto.pcb.Pid,
-		TidActual: contexto.tcb.Tid,
+		Path:       archivoInstruct,
+		Size:       tamArchReal,
+		Prioridad:  priorityReal,
+		PidActual:  contexto.pcb.Pid,
+		TidActual:  contexto.tcb.Tid,
+		TiempoReal: tiempoReal,
 	})
 	if err != nil {
 		log.Printf("Error al codificar estructura de creación de proceso: %v", err)
@@ -877,8 +1114,13 @@ func CreateThead(contexto *contextoEjecucion, parameters []string) error { //EST
 	return nil
 }
 
+// THREAD_JOIN <tid> [registro], el kernel deja en el registro el codigo de salida del hilo
 func JoinThead(contexto *contextoEjecucion, parameters []string) error {
 	tid := parameters[0]
+	var registro string
+	if len(parameters) > 1 {
+		registro = parameters[1]
+	}
 
 	err := ActualizarContextoDeEjecucion(contexto)
 	if err != nil {
@@ -895,6 +1137,7 @@ func JoinThead(contexto *contextoEjecucion, parameters []string) error {
 		Pid:       contexto.pcb.Pid,
 		TidActual: contexto.tcb.Tid,
 		TidCambio: tidParse,
+		Registro:  registro,
 	})
 	if err != nil {
 		log.Printf("Error al codificar estructura de cambio de hilo")
@@ -967,6 +1210,26 @@ func MutexUNLOCK(contexto *contextoEjecucion, parameters []string) error {
 	syscallEnviada = true
 	return nil
 }
+func MutexTRYLOCK(contexto *contextoEjecucion, parameters []string) error {
+	err := MutexFunctionConResultado(contexto, parameters[0], 0, parameters[1], "intentarBloquearMutex")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func MutexTIMEDLOCK(contexto *contextoEjecucion, parameters []string) error {
+	tiempo, err := strconv.Atoi(parameters[1])
+	if err != nil {
+		return fmt.Errorf("error al convertir tiempo de espera: %v", err)
+	}
+	err = MutexFunctionConResultado(contexto, parameters[0], tiempo, parameters[2], "bloquearMutexConTiempo")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
 func MutexFunction(contexto *contextoEjecucion, parameters []string, endpoint string) error {
 	recurso := parameters[0]
 
@@ -993,16 +1256,160 @@ func MutexFunction(contexto *contextoEjecucion, parameters []string, endpoint st
 	}
 	return nil
 }
+func MutexFunctionConResultado(contexto *contextoEjecucion, recurso string, tiempo int, registro string, endpoint string) error {
+	err := ActualizarContextoDeEjecucion(contexto)
+	if err != nil {
+		log.Printf("Error al actualziar contexto de ejecucion")
+		return err
+	}
+
+	body, err := json.Marshal(MutexRequest{
+		Pid:      contexto.pcb.Pid,
+		Tid:      contexto.tcb.Tid,
+		Mutex:    recurso,
+		Registro: registro,
+		Tiempo:   tiempo,
+	})
+	if err != nil {
+		log.Printf("Error al codificar estructura de mutex")
+		return err
+	}
+	err = EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), endpoint)
+	if err != nil {
+		log.Printf("Error syscall %s : %v", endpoint, err)
+		return err
+	}
+	return nil
+}
+func SemCreate(contexto *contextoEjecucion, parameters []string) error {
+	valorInicial, err := strconv.Atoi(parameters[1])
+	if err != nil {
+		return fmt.Errorf("error al convertir valor inicial del semaforo: %v", err)
+	}
+	err = SemaforoFunction(contexto, parameters[0], valorInicial, "crearSemaforo")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func SemWait(contexto *contextoEjecucion, parameters []string) error {
+	err := SemaforoFunction(contexto, parameters[0], 0, "esperarSemaforo")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func SemPost(contexto *contextoEjecucion, parameters []string) error {
+	err := SemaforoFunction(contexto, parameters[0], 0, "senalarSemaforo")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func SemaforoFunction(contexto *contextoEjecucion, semaforo string, valor int, endpoint string) error {
+	err := ActualizarContextoDeEjecucion(contexto)
+	if err != nil {
+		log.Printf("Error al actualziar contexto de ejecucion")
+		return err
+	}
+
+	body, err := json.Marshal(SemaforoRequest{
+		Pid:      contexto.pcb.Pid,
+		Tid:      contexto.tcb.Tid,
+		Semaforo: semaforo,
+		Valor:    valor,
+	})
+	if err != nil {
+		log.Printf("Error al codificar estructura de semaforo")
+		return err
+	}
+	err = EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), endpoint)
+	if err != nil {
+		log.Printf("Error syscall %s : %v", endpoint, err)
+		return err
+	}
+	return nil
+}
+func CondCreate(contexto *contextoEjecucion, parameters []string) error {
+	err := CondicionFunction(contexto, parameters[0], "", "crearCondicion")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func CondWait(contexto *contextoEjecucion, parameters []string) error {
+	err := CondicionFunction(contexto, parameters[0], parameters[1], "esperarCondicion")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func CondSignal(contexto *contextoEjecucion, parameters []string) error {
+	err := CondicionFunction(contexto, parameters[0], "", "senalarCondicion")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func CondBroadcast(contexto *contextoEjecucion, parameters []string) error {
+	err := CondicionFunction(contexto, parameters[0], "", "difundirCondicion")
+	if err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+func CondicionFunction(contexto *contextoEjecucion, condicion string, mutex string, endpoint string) error {
+	err := ActualizarContextoDeEjecucion(contexto)
+	if err != nil {
+		log.Printf("Error al actualziar contexto de ejecucion")
+		return err
+	}
+
+	body, err := json.Marshal(CondicionRequest{
+		Pid:       contexto.pcb.Pid,
+		Tid:       contexto.tcb.Tid,
+		Condicion: condicion,
+		Mutex:     mutex,
+	})
+	if err != nil {
+		log.Printf("Error al codificar estructura de variable de condicion")
+		return err
+	}
+	err = EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), endpoint)
+	if err != nil {
+		log.Printf("Error syscall %s : %v", endpoint, err)
+		return err
+	}
+	return nil
+}
 
+// THREAD_EXIT [registro], el valor del registro es el codigo de salida del hilo
 func ThreadExit(contexto *contextoEjecucion, parameters []string) error {
 	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
 		log.Printf("Error al actualizar contexto de ejecución: %v", err)
 		return err
 	}
 
+	var codigo uint32
+	if len(parameters) > 0 {
+		valor, err := ObtenerValorCampo(reflect.ValueOf(&contexto.tcb), parameters[0])
+		if err != nil {
+			return err
+		}
+		codigo = valor
+	}
+
 	process := KernelExeReq{
-		Pid: contexto.pcb.Pid,
-		Tid: contexto.tcb.Tid,
+		Pid:    contexto.pcb.Pid,
+		Tid:    contexto.tcb.Tid,
+		Codigo: codigo,
 	}
 
 	body, err := json.Marshal(process)
@@ -1017,6 +1424,242 @@ func ThreadExit(contexto *contextoEjecucion, parameters []string) error {
 	return nil
 }
 
+// PROCESS_WAIT <pid|ANY> <registro>, el kernel deja en el registro el estado de salida del hijo
+func ProcessWait(contexto *contextoEjecucion, parameters []string) error {
+	hijo := -1
+	if parameters[0] != "ANY" {
+		pid, err := strconv.Atoi(parameters[0])
+		if err != nil {
+			return err
+		}
+		hijo = pid
+	}
+
+	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
+		log.Printf("Error al actualizar contexto de ejecución: %v", err)
+		return err
+	}
+
+	body, err := json.Marshal(EsperaProcesoBody{
+		Pid:      contexto.pcb.Pid,
+		Tid:      contexto.tcb.Tid,
+		Hijo:     hijo,
+		Registro: parameters[1],
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), "esperarProceso"); err != nil {
+		log.Printf("Error syscall PROCESS_WAIT : %v", err)
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+
+// AtenderSenal guarda el contexto del hilo en memoria y salta al manejador de la señal
+func AtenderSenal(contexto *contextoEjecucion, senal SenalEntregada) error {
+	body, err := json.Marshal(KernelExeReq{
+		Pid: contexto.pcb.Pid,
+		Tid: contexto.tcb.Tid,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpMemoria, ConfigsCpu.PuertoMemoria, bytes.NewBuffer(body), "guardarContexto"); err != nil {
+		return err
+	}
+	log.Printf("## TID: %d - Atiende la señal %d - Salta al PC %d", contexto.tcb.Tid, senal.Senal, senal.Manejador)
+	contexto.tcb.PC = senal.Manejador
+	return nil
+}
+
+// SIGNAL <pid> <señal>
+func Signal(contexto *contextoEjecucion, parameters []string) error {
+	destino, err := strconv.Atoi(parameters[0])
+	if err != nil {
+		return err
+	}
+	senal, err := strconv.Atoi(parameters[1])
+	if err != nil {
+		return err
+	}
+	return SenalFunction(contexto, SenalBody{Destino: destino, Senal: senal}, "enviarSenal")
+}
+
+// SIGNAL_HANDLER <señal> <pc>
+func SignalHandler(contexto *contextoEjecucion, parameters []string) error {
+	senal, err := strconv.Atoi(parameters[0])
+	if err != nil {
+		return err
+	}
+	pc, err := strconv.Atoi(parameters[1])
+	if err != nil {
+		return err
+	}
+	return SenalFunction(contexto, SenalBody{Senal: senal, Pc: uint32(pc)}, "manejadorSenal")
+}
+
+func SenalFunction(contexto *contextoEjecucion, senal SenalBody, endpoint string) error {
+	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
+		log.Printf("Error al actualizar contexto de ejecución: %v", err)
+		return err
+	}
+
+	senal.Pid = contexto.pcb.Pid
+	senal.Tid = contexto.tcb.Tid
+	body, err := json.Marshal(senal)
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), endpoint); err != nil {
+		log.Printf("Error syscall %s : %v", endpoint, err)
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+
+// SIGNAL_RETURN vuelve del manejador de una señal al contexto que se guardo al atenderla
+func SignalReturn(contexto *contextoEjecucion, parameters []string) error {
+	body, err := json.Marshal(KernelExeReq{
+		Pid: contexto.pcb.Pid,
+		Tid: contexto.tcb.Tid,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpMemoria, ConfigsCpu.PuertoMemoria, bytes.NewBuffer(body), "restaurarContexto"); err != nil {
+		return err
+	}
+	*contexto = GetContextoEjecucion(contexto.pcb.Pid, contexto.tcb.Tid)
+	return nil
+}
+
+// YIELD, el hilo vuelve a READY
+func Yield(contexto *contextoEjecucion, parameters []string) error {
+	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
+		log.Printf("Error al actualizar contexto de ejecución: %v", err)
+		return err
+	}
+
+	body, err := json.Marshal(KernelExeReq{
+		Pid: contexto.pcb.Pid,
+		Tid: contexto.tcb.Tid,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), "cederCpu"); err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+
+// SLEEP <ms>
+func Sleep(contexto *contextoEjecucion, parameters []string) error {
+	tiempo, err := strconv.Atoi(parameters[0])
+	if err != nil {
+		return err
+	}
+
+	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
+		log.Printf("Error al actualizar contexto de ejecución: %v", err)
+		return err
+	}
+
+	body, err := json.Marshal(DormirBody{
+		Pid:    contexto.pcb.Pid,
+		Tid:    contexto.tcb.Tid,
+		Tiempo: tiempo,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), "dormirHilo"); err != nil {
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+
+// SHM_CREATE <nombre> <tamaño> <registro>, el kernel deja en el registro 0 si se creo la region
+func ShmCreate(contexto *contextoEjecucion, parameters []string) error {
+	tamanio, err := strconv.Atoi(parameters[1])
+	if err != nil {
+		return err
+	}
+	return MemoriaCompartidaFunction(contexto, MemoriaCompartidaBody{Nombre: parameters[0], Tamanio: tamanio, Registro: parameters[2]}, "crearMemoriaCompartida")
+}
+
+// SHM_ATTACH <nombre> <registro>, el kernel deja en el registro la direccion logica de la region
+func ShmAttach(contexto *contextoEjecucion, parameters []string) error {
+	return MemoriaCompartidaFunction(contexto, MemoriaCompartidaBody{Nombre: parameters[0], Registro: parameters[1]}, "adjuntarMemoriaCompartida")
+}
+
+func MemoriaCompartidaFunction(contexto *contextoEjecucion, solicitud MemoriaCompartidaBody, endpoint string) error {
+	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
+		log.Printf("Error al actualizar contexto de ejecución: %v", err)
+		return err
+	}
+
+	solicitud.Pid = contexto.pcb.Pid
+	solicitud.Tid = contexto.tcb.Tid
+	body, err := json.Marshal(solicitud)
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), endpoint); err != nil {
+		log.Printf("Error syscall %s : %v", endpoint, err)
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+
+// MQ_SEND <cola> <registro>, manda el valor del registro
+func MqSend(contexto *contextoEjecucion, parameters []string) error {
+	valor, err := ObtenerValorCampo(reflect.ValueOf(&contexto.tcb), parameters[1])
+	if err != nil {
+		return err
+	}
+	return MensajeFunction(contexto, MensajeBody{Cola: parameters[0], Valor: valor}, "enviarMensaje")
+}
+
+// MQ_RECEIVE <cola> <registro>, el kernel deja el mensaje en el registro
+func MqReceive(contexto *contextoEjecucion, parameters []string) error {
+	return MensajeFunction(contexto, MensajeBody{Cola: parameters[0], Registro: parameters[1]}, "recibirMensaje")
+}
+
+func MensajeFunction(contexto *contextoEjecucion, mensaje MensajeBody, endpoint string) error {
+	if err := ActualizarContextoDeEjecucion(contexto); err != nil {
+		log.Printf("Error al actualizar contexto de ejecución: %v", err)
+		return err
+	}
+
+	mensaje.Pid = contexto.pcb.Pid
+	mensaje.Tid = contexto.tcb.Tid
+	body, err := json.Marshal(mensaje)
+	if err != nil {
+		return err
+	}
+
+	if err := EnviarAModulo(ConfigsCpu.IpKernel, ConfigsCpu.PuertoKernel, bytes.NewBuffer(body), endpoint); err != nil {
+		log.Printf("Error syscall %s : %v", endpoint, err)
+		return err
+	}
+	syscallEnviada = true
+	return nil
+}
+
 func ProcessExit(contexto *contextoEjecucion, parameters []string) error {
 	log.Printf("Finalizando proceso PID: %d", contexto.pcb.Pid)
 
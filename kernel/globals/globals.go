@@ -1,16 +1,36 @@
 package globals
 
+type CpuConfig struct {
+	Ip     string `json:"ip"`
+	Puerto int    `json:"puerto"`
+}
+
 type Config struct {
-	Puerto                 int    `json:"puerto"`                  //Puerto en el cual escuchará el servidor
-	IpMemoria              string `json:"ip_memoria"`              //IP a la cual se deberá conectar con la Memoria
-	PuertoMemoria          int    `json:"puerto_memoria"`          //Puerto al cual se deberá conectar con la Memoria
-	IpCpu                  string `json:"ip_cpu"`                  //IP a la cual se deberá conectar con el Kernel
-	PuertoCpu              int    `json:"puerto_cpu"`              //Puerto al cual se deberá conectar con el Kernel
-	AlgoritmoPlanificacion string `json:"algoritmo_planificacion"` //Algoritmo de planificación a utilizar
-	Quantum                int    `json:"quantum"`                 //Quantum de tiempo a utilizar en el algoritmo de planificación
-	LogLevel               string `json:"log_level"`               //Nivel de detalle máximo a mostrar.
-	ArchivoInicial         string `json:"archivo_inicial"`         //Archivo de configuración inicial
-	SizeInicial            int    `json:"size_inicial"`            //Tamaño de la memoria inicial
+	Puerto                   int         `json:"puerto"`                    //Puerto en el cual escuchará el servidor
+	IpMemoria                string      `json:"ip_memoria"`                //IP a la cual se deberá conectar con la Memoria
+	PuertoMemoria            int         `json:"puerto_memoria"`            //Puerto al cual se deberá conectar con la Memoria
+	IpCpu                    string      `json:"ip_cpu"`                    //IP a la cual se deberá conectar con el Kernel
+	PuertoCpu                int         `json:"puerto_cpu"`                //Puerto al cual se deberá conectar con el Kernel
+	Cpus                     []CpuConfig `json:"cpus"`                      //CPUs a las que despachar hilos, si esta vacio se usa ip_cpu/puerto_cpu
+	AlgoritmoPlanificacion   string      `json:"algoritmo_planificacion"`   //Algoritmo de planificación a utilizar
+	Quantum                  int         `json:"quantum"`                   //Quantum de tiempo a utilizar en el algoritmo de planificación
+	LogLevel                 string      `json:"log_level"`                 //Nivel de detalle máximo a mostrar.
+	ArchivoInicial           string      `json:"archivo_inicial"`           //Archivo de configuración inicial
+	SizeInicial              int         `json:"size_inicial"`              //Tamaño de la memoria inicial
+	QuantumPorNivel          map[int]int `json:"quantum_por_nivel"`         //Quantum de cada nivel en CMN, los niveles no listados usan quantum
+	RetroalimentacionCMN     bool        `json:"retroalimentacion_cmn"`     //En CMN los hilos bajan de nivel al agotar el quantum y suben al dejar la CPU antes
+	IntervaloEnvejecimiento  int         `json:"intervalo_envejecimiento"`  //Cada cuantos ms en READY mejora la prioridad en PRIORIDADES y CMN, 0 sin envejecimiento
+	IncrementoEnvejecimiento int         `json:"incremento_envejecimiento"` //Cuanto mejora la prioridad por cada intervalo de envejecimiento
+	Alfa                     float64     `json:"alfa"`                      //Peso de la ultima rafaga en la estimacion de SJF y SRT, entre 0 y 1 (si no se indica se usa 0.5)
+	EstimacionInicial        int         `json:"estimacion_inicial"`        //Estimacion en ms de la primera rafaga de cada hilo en SJF y SRT
+	PoliticaAdmision         string      `json:"politica_admision"`         //Orden de admision de procesos: FIFO, MAS_CHICO_PRIMERO, PRIORIDADES o PRIMERO_QUE_ENTRA
+	NivelMaximoCMN           int         `json:"nivel_maximo_cmn"`          //Nivel mas bajo al que puede descender un hilo en CMN, 0 sin limite
+	Consola                  bool        `json:"consola"`                   //Lee comandos de operador por stdin
+	PoliticaDeadlock         string      `json:"politica_deadlock"`         //Ante un deadlock: REPORTAR (por defecto) o FINALIZAR_VICTIMA
+	HerenciaPrioridad        bool        `json:"herencia_prioridad"`        //En PRIORIDADES y CMN el hilo que tiene un mutex hereda la prioridad de los que lo esperan
+	DispositivosIO           []string    `json:"dispositivos_io"`           //Dispositivos de IO con nombre, cada uno atiende un pedido a la vez (ej: DISCO, TECLADO)
+	CapacidadColaMensajes    int         `json:"capacidad_cola_mensajes"`   //Mensajes que entran en cada cola de MQ_SEND/MQ_RECEIVE (si no se indica se usa 10)
+	ArchivoCheckpoint        string      `json:"archivo_checkpoint"`        //Archivo donde POST /checkpoint guarda el estado si no se indica otro (por defecto kernel_checkpoint.json)
 }
 
 var ClientConfig *Config
@@ -24,6 +24,13 @@ func main() {
 
 	http.HandleFunc("POST /crearProceso", utils.CrearProceso)
 	http.HandleFunc("POST /finalizarProceso", utils.FinalizarProceso)
+	http.HandleFunc("POST /esperarProceso", utils.EsperarProceso)
+	http.HandleFunc("POST /enviarSenal", utils.EnviarSenal)
+	http.HandleFunc("POST /manejadorSenal", utils.RegistrarManejadorSenal)
+	http.HandleFunc("POST /enviarMensaje", utils.EnviarMensaje)
+	http.HandleFunc("POST /recibirMensaje", utils.RecibirMensaje)
+	http.HandleFunc("POST /crearMemoriaCompartida", utils.CrearMemoriaCompartida)
+	http.HandleFunc("POST /adjuntarMemoriaCompartida", utils.AdjuntarMemoriaCompartida)
 
 	http.HandleFunc("POST /crearHilo", utils.CrearHilo)
 	http.HandleFunc("POST /finalizarHilo", utils.FinalizarHilo)
@@ -33,15 +40,48 @@ func main() {
 	http.HandleFunc("POST /crearMutex", utils.CrearMutex)
 	http.HandleFunc("POST /bloquearMutex", utils.BloquearMutex)
 	http.HandleFunc("POST /liberarMutex", utils.LiberarMutex)
+	http.HandleFunc("POST /intentarBloquearMutex", utils.IntentarBloquearMutex)
+	http.HandleFunc("POST /bloquearMutexConTiempo", utils.BloquearMutexConTiempo)
+
+	http.HandleFunc("POST /crearSemaforo", utils.CrearSemaforo)
+	http.HandleFunc("POST /esperarSemaforo", utils.EsperarSemaforo)
+	http.HandleFunc("POST /senalarSemaforo", utils.SenalarSemaforo)
+
+	http.HandleFunc("POST /crearCondicion", utils.CrearCondicion)
+	http.HandleFunc("POST /esperarCondicion", utils.EsperarCondicion)
+	http.HandleFunc("POST /senalarCondicion", utils.SenalarCondicion)
+	http.HandleFunc("POST /difundirCondicion", utils.DifundirCondicion)
 
 	http.HandleFunc("POST /manejarIo", utils.ManejarIo)
 
 	http.HandleFunc("POST /devolverPidTid", utils.DevolverPidTid)
+	http.HandleFunc("POST /cederCpu", utils.CederCpu)
+	http.HandleFunc("POST /dormirHilo", utils.DormirHilo)
 
 	http.HandleFunc("POST /dumpMemory", utils.DumpMemory)
 
 	http.HandleFunc("POST /segmentationFault", utils.SegmentationFault)
 
+	http.HandleFunc("POST /registrarCpu", utils.RegistrarCpu)
+
+	http.HandleFunc("GET /procesos", utils.ListarProcesos)
+	http.HandleFunc("GET /procesos/{pid}", utils.ConsultarProceso)
+	http.HandleFunc("GET /hilos", utils.ListarHilos)
+	http.HandleFunc("GET /mutex", utils.ListarMutex)
+	http.HandleFunc("GET /io", utils.ListarDispositivosIO)
+	http.HandleFunc("GET /metrics", utils.Metricas)
+	http.HandleFunc("POST /checkpoint", utils.GuardarCheckpoint)
+
+	http.HandleFunc("POST /procesos", utils.SometerProceso)
+	http.HandleFunc("DELETE /procesos/{pid}", utils.MatarProceso)
+	http.HandleFunc("DELETE /procesos/{pid}/hilos/{tid}", utils.CancelarHiloOperador)
+	http.HandleFunc("POST /procesos/{pid}/suspender", utils.SuspenderProceso)
+	http.HandleFunc("POST /procesos/{pid}/reanudar", utils.ReanudarProceso)
+
+	if globals.ClientConfig.Consola {
+		go utils.IniciarConsola()
+	}
+
 	//Escuchar (bloqueante)
 	http.ListenAndServe(":"+strconv.Itoa(puerto), nil)
 
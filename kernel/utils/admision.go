@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+/*---------------------- PLANIFICADOR DE LARGO PLAZO ----------------------*/
+
+// PoliticaAdmision decide en que orden se intenta pasar a memoria a los
+// procesos que esperan en colaProcesosSinIniciar.
+type PoliticaAdmision interface {
+	Nombre() string
+	// Candidatos devuelve los procesos en el orden en que se intenta admitirlos
+	Candidatos(cola []Proceso) []Proceso
+	// SeguirSiNoEntra indica si se prueba con el resto cuando un proceso no entra en memoria
+	SeguirSiNoEntra() bool
+}
+
+var politicaAdmision PoliticaAdmision
+
+// Evita que dos admisiones consulten a memoria por el mismo proceso a la vez
+var mutexAdmision sync.Mutex
+
+func crearPoliticaAdmision(politica string) (PoliticaAdmision, error) {
+	switch politica {
+	case "", "FIFO":
+		return admisionFIFO{}, nil
+	case "MAS_CHICO_PRIMERO":
+		return admisionMasChicoPrimero{}, nil
+	case "PRIORIDADES":
+		return admisionPrioridades{}, nil
+	case "PRIMERO_QUE_ENTRA":
+		return admisionPrimeroQueEntra{}, nil
+	default:
+		return nil, fmt.Errorf("politica de admision %s no valida", politica)
+	}
+}
+
+func encolarProcesoSinIniciar(proceso Proceso) {
+	mutexProcesosSinIniciar.Lock()
+	colaProcesosSinIniciar = append(colaProcesosSinIniciar, proceso)
+	mutexProcesosSinIniciar.Unlock()
+
+	log.Printf(" ## (<PID>:%d) Se crea el proceso - Estado: NEW ##", proceso.PCB.Pid)
+}
+
+func quitarProcesoSinIniciar(pid int) {
+	mutexProcesosSinIniciar.Lock()
+	for i, p := range colaProcesosSinIniciar {
+		if p.PCB.Pid == pid {
+			colaProcesosSinIniciar = append(colaProcesosSinIniciar[:i], colaProcesosSinIniciar[i+1:]...)
+			break
+		}
+	}
+	mutexProcesosSinIniciar.Unlock()
+}
+
+// admitirProcesos intenta pasar a memoria a los procesos que esperan, en el
+// orden de la politica de admision. Se llama cada vez que se crea un proceso y
+// cada vez que se libera memoria.
+func admitirProcesos() {
+	mutexAdmision.Lock()
+	defer mutexAdmision.Unlock()
+
+	mutexProcesosSinIniciar.Lock()
+	cola := append([]Proceso(nil), colaProcesosSinIniciar...)
+	mutexProcesosSinIniciar.Unlock()
+
+	if len(cola) == 0 {
+		return
+	}
+
+	for _, proceso := range politicaAdmision.Candidatos(cola) {
+		if admitirProceso(proceso) {
+			continue
+		}
+		if !politicaAdmision.SeguirSiNoEntra() {
+			return
+		}
+	}
+}
+
+func admitirProceso(proceso Proceso) bool {
+	pcb := proceso.PCB
+
+	estadoMemoria := consultaEspacioAMemoria(proceso.Size, pcb)
+
+	if estadoMemoria == Compactar {
+		planificador.iniciarCompactacion()
+		compactar()
+		estadoMemoria = consultaEspacioAMemoria(proceso.Size, pcb)
+		planificador.finalizarCompactacion()
+	}
+
+	switch estadoMemoria {
+	case HayEspacio:
+		tcb := createTCB(pcb.Pid, proceso.Prioridad)
+		pcb.Tid = append(pcb.Tid, tcb.Tid)
+
+		enviarTCBMemoria(tcb, proceso.Path)
+
+		quitarProcesoSinIniciar(pcb.Pid)
+		encolarProcesoInicializado(pcb)
+		encolarReady(tcb)
+		return true
+	case NoHayEspacio, Compactar:
+		log.Printf("## (<PID: %d >) NO HAY PARTICIONES DISPONIBLES PARA SU TAMANIO", pcb.Pid)
+	default:
+		slog.Error("Error en el estado de la memoria")
+	}
+	return false
+}
+
+/*---------- POLITICAS DE ADMISION ----------*/
+
+// FIFO: solo se intenta con el primero, el resto espera aunque entre en memoria
+type admisionFIFO struct{}
+
+func (admisionFIFO) Nombre() string { return "FIFO" }
+
+func (admisionFIFO) Candidatos(cola []Proceso) []Proceso { return cola }
+
+func (admisionFIFO) SeguirSiNoEntra() bool { return false }
+
+// MAS_CHICO_PRIMERO: se admite de menor a mayor tamaño hasta el primero que no entra
+type admisionMasChicoPrimero struct{}
+
+func (admisionMasChicoPrimero) Nombre() string { return "MAS_CHICO_PRIMERO" }
+
+func (admisionMasChicoPrimero) Candidatos(cola []Proceso) []Proceso {
+	sort.SliceStable(cola, func(i, j int) bool { return cola[i].Size < cola[j].Size })
+	return cola
+}
+
+func (admisionMasChicoPrimero) SeguirSiNoEntra() bool { return false }
+
+// PRIORIDADES: se admite por prioridad del hilo main, a igual prioridad por orden de llegada
+type admisionPrioridades struct{}
+
+func (admisionPrioridades) Nombre() string { return "PRIORIDADES" }
+
+func (admisionPrioridades) Candidatos(cola []Proceso) []Proceso {
+	sort.SliceStable(cola, func(i, j int) bool { return cola[i].Prioridad < cola[j].Prioridad })
+	return cola
+}
+
+func (admisionPrioridades) SeguirSiNoEntra() bool { return false }
+
+// PRIMERO_QUE_ENTRA: por orden de llegada, salteando a los que no entran en memoria
+type admisionPrimeroQueEntra struct{}
+
+func (admisionPrimeroQueEntra) Nombre() string { return "PRIMERO_QUE_ENTRA" }
+
+func (admisionPrimeroQueEntra) Candidatos(cola []Proceso) []Proceso { return cola }
+
+func (admisionPrimeroQueEntra) SeguirSiNoEntra() bool { return true }
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*---------------------- YIELD Y SLEEP ----------------------*/
+
+// YIELD devuelve el hilo a READY por el mismo camino que un desalojo, asi un
+// hilo que espera activamente no consume todo su quantum. SLEEP <ms> bloquea
+// al hilo con un timer del kernel, sin ocupar ningun dispositivo de IO.
+
+type DormirRequest struct {
+	Pid    int `json:"pid"`
+	Tid    int `json:"tid"`
+	Tiempo int `json:"tiempo"` // en milisegundos
+}
+
+var hilosDormidos = make(map[claveHilo]*time.Timer)
+var mutexHilosDormidos sync.Mutex
+
+func CederCpu(w http.ResponseWriter, r *http.Request) {
+	var hilo TCBRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&hilo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := hilo.Pid
+	tid := hilo.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <YIELD> ##", pid, tid)
+
+	tcbActual := getTCB(pid, tid)
+	devolverAReady(tcbActual)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func DormirHilo(w http.ResponseWriter, r *http.Request) {
+	var dormir DormirRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&dormir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := dormir.Pid
+	tid := dormir.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SLEEP> ##", pid, tid)
+
+	tcbActual := getTCB(pid, tid)
+	if dormir.Tiempo <= 0 {
+		enviarTCBCpu(tcbActual)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	quitarExec(tcbActual)
+	encolarBlock(tcbActual, "SLEEP")
+
+	clave := claveHilo{pid, tid}
+	mutexHilosDormidos.Lock()
+	hilosDormidos[clave] = time.AfterFunc(time.Duration(dormir.Tiempo)*time.Millisecond, func() {
+		mutexHilosDormidos.Lock()
+		delete(hilosDormidos, clave)
+		mutexHilosDormidos.Unlock()
+
+		log.Printf("## (<PID:%d>:<TID:%d>) - Finaliza SLEEP ##", pid, tid)
+		despertarHiloBloqueado(tcbActual)
+	})
+	mutexHilosDormidos.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// cancelarSueno detiene el timer del hilo si estaba dormido
+func cancelarSueno(hilo TCB) {
+	mutexHilosDormidos.Lock()
+	defer mutexHilosDormidos.Unlock()
+
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	if timer, dormido := hilosDormidos[clave]; dormido {
+		timer.Stop()
+		delete(hilosDormidos, clave)
+	}
+}
@@ -0,0 +1,887 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+/*---------------------- CHECKPOINT Y RESTAURACION ----------------------*/
+
+// POST /checkpoint guarda en un JSON versionado las colas de procesos y de
+// hilos, nextPid/nextTid, el estado de los mutex, semaforos y condiciones de
+// cada proceso, el arbol de procesos con sus zombies y esperas de
+// PROCESS_WAIT, los codigos de salida de los hilos, las colas de mensajes, los
+// manejadores de señales, los procesos de tiempo real y las CPUs del pool. Con
+// `kernel <config> --restore <archivo>` el kernel arranca desde ese archivo en
+// lugar de crear el proceso inicial. Antes de mirar memoria reinicia las CPUs
+// (las de la configuracion y las del checkpoint): cada una abandona el hilo que
+// ejecutaba y responde recien cuando dejo de ejecutarlo. Los hilos en EXEC solo
+// se pueden volver a despachar despues de ese reinicio, si no el mismo hilo
+// podria correr en dos CPUs. Despues reconcilia con los PID/TID que memoria
+// tiene cargados:
+//   - los procesos e hilos que memoria ya no tiene pasan a EXIT, como si los
+//     hubiera finalizado el operador;
+//   - los que memoria tiene y el kernel no se finalizan en memoria;
+//   - los hilos en EXEC vuelven a READY desde el ultimo contexto que la CPU
+//     guardo en memoria;
+//   - las esperas con tiempo de MUTEX_TIMEDLOCK se dan por vencidas;
+//   - los THREAD_JOIN a un hilo que se perdio reciben CodigoSalidaCancelado;
+//   - los hilos bloqueados por algo que no se guarda (IO, SLEEP, suspension)
+//     vuelven a READY como si la syscall hubiera terminado.
+// Tambien se guarda el estado propio del algoritmo de planificacion (niveles
+// de CMN, estimaciones de SJF/SRT, tiempos virtuales de FAIR), que solo se
+// restaura si el kernel arranca con el mismo algoritmo.
+
+const versionCheckpoint = 1
+const archivoCheckpointPorDefecto = "kernel_checkpoint.json"
+
+type Checkpoint struct {
+	Version               int                        `json:"version"`
+	Fecha                 time.Time                  `json:"fecha"`
+	NextPid               int                        `json:"nextPid"`
+	NextTid               []int                      `json:"nextTid"`
+	PidInicial            int                        `json:"pidInicial"`
+	ProcesosSinIniciar    []ProcesoCheckpoint        `json:"procesosSinIniciar"`
+	ProcesosInicializados []PCBCheckpoint            `json:"procesosInicializados"`
+	ProcesosFinalizados   []PCBCheckpoint            `json:"procesosFinalizados"`
+	Arbol                 []NodoProcesoCheckpoint    `json:"arbol"`
+	HilosReady            []TCB                      `json:"hilosReady"`
+	HilosExec             []TCB                      `json:"hilosExec"`
+	HilosBlock            []TCB                      `json:"hilosBlock"`
+	HilosExit             []TCB                      `json:"hilosExit"`
+	EsperasMutex          []EsperaMutexCheckpoint    `json:"esperasMutex"`
+	RegistrosJoin         []RegistroJoinCheckpoint   `json:"registrosJoin"`
+	EsperasJoin           []EsperaJoinCheckpoint     `json:"esperasJoin"`
+	EsperasProceso        []EsperaProcesoCheckpoint  `json:"esperasProceso"`
+	HilosFinalizados      []HiloFinalizadoCheckpoint `json:"hilosFinalizados"`
+	ColasMensajes         []ColaMensajesCheckpoint   `json:"colasMensajes"`
+	Senales               []SenalesCheckpoint        `json:"senales"`
+	TiempoReal            []TiempoRealCheckpoint     `json:"tiempoReal"`
+	Cpus                  []CpuRequest               `json:"cpus"`
+	Algoritmo             string                     `json:"algoritmo"`
+	EstadoAlgoritmo       json.RawMessage            `json:"estadoAlgoritmo,omitempty"`
+}
+
+type ProcesoCheckpoint struct {
+	PCB       PCBCheckpoint `json:"pcb"`
+	Size      int           `json:"size"`
+	Path      string        `json:"path"`
+	Prioridad int           `json:"prioridad"`
+}
+
+type PCBCheckpoint struct {
+	Pid         int                   `json:"pid"`
+	Tid         []int                 `json:"tid"`
+	Mutex       []MutexCheckpoint     `json:"mutex"`
+	Semaforos   []SemaforoCheckpoint  `json:"semaforos"`
+	Condiciones []CondicionCheckpoint `json:"condiciones"`
+}
+
+type MutexCheckpoint struct {
+	Nombre         string `json:"nombre"`
+	Bloqueado      bool   `json:"bloqueado"`
+	HiloUsando     int    `json:"hiloUsando"`
+	ColaBloqueados []TCB  `json:"colaBloqueados"`
+}
+
+type SemaforoCheckpoint struct {
+	Nombre         string `json:"nombre"`
+	Valor          int    `json:"valor"`
+	ColaBloqueados []TCB  `json:"colaBloqueados"`
+}
+
+type CondicionCheckpoint struct {
+	Nombre         string                      `json:"nombre"`
+	ColaBloqueados []EsperaCondicionCheckpoint `json:"colaBloqueados"`
+}
+
+type EsperaCondicionCheckpoint struct {
+	Hilo  TCB    `json:"hilo"`
+	Mutex string `json:"mutex"`
+}
+
+type EsperaMutexCheckpoint struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Mutex    string `json:"mutex"`
+	Registro string `json:"registro"`
+}
+
+type RegistroJoinCheckpoint struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Registro string `json:"registro"`
+}
+
+type EsperaJoinCheckpoint struct {
+	Pid   int   `json:"pid"`
+	Tid   int   `json:"tid"` // hilo esperado
+	Hilos []int `json:"hilos"`
+}
+
+type NodoProcesoCheckpoint struct {
+	Pid        int    `json:"pid"`
+	Padre      int    `json:"padre"`
+	Finalizado bool   `json:"finalizado"`
+	Estado     uint32 `json:"estado"`
+}
+
+type EsperaProcesoCheckpoint struct {
+	Hilo     TCB    `json:"hilo"`
+	Hijo     int    `json:"hijo"`
+	Registro string `json:"registro"`
+}
+
+type HiloFinalizadoCheckpoint struct {
+	Pid    int    `json:"pid"`
+	Tid    int    `json:"tid"`
+	Codigo uint32 `json:"codigo"`
+}
+
+type ColaMensajesCheckpoint struct {
+	Nombre     string                `json:"nombre"`
+	Capacidad  int                   `json:"capacidad"`
+	Mensajes   []uint32              `json:"mensajes"`
+	Emisores   []EnvioCheckpoint     `json:"emisores"`
+	Receptores []RecepcionCheckpoint `json:"receptores"`
+	Procesos   []int                 `json:"procesos"`
+}
+
+type EnvioCheckpoint struct {
+	Hilo  TCB    `json:"hilo"`
+	Valor uint32 `json:"valor"`
+}
+
+type RecepcionCheckpoint struct {
+	Hilo     TCB    `json:"hilo"`
+	Registro string `json:"registro"`
+}
+
+type SenalesCheckpoint struct {
+	Pid         int            `json:"pid"`
+	Manejadores map[int]uint32 `json:"manejadores"`
+	Pendientes  []int          `json:"pendientes"`
+}
+
+type TiempoRealCheckpoint struct {
+	Pid        int                  `json:"pid"`
+	Parametros ParametrosTiempoReal `json:"parametros"`
+}
+
+type CheckpointRequest struct {
+	Archivo string `json:"archivo"`
+}
+
+type CheckpointResponse struct {
+	Archivo string `json:"archivo"`
+	Version int    `json:"version"`
+}
+
+// Lo que memoria tiene cargado, ver GET /hilos de memoria
+type hilosVivosMemoria struct {
+	Procesos []int        `json:"procesos"`
+	Hilos    []TCBRequest `json:"hilos"`
+}
+
+func GuardarCheckpoint(w http.ResponseWriter, r *http.Request) {
+	// El cuerpo es opcional, sin archivo se usa el de la configuracion
+	var solicitud CheckpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&solicitud); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	archivo := solicitud.Archivo
+	if archivo == "" {
+		archivo = ConfigKernel.ArchivoCheckpoint
+	}
+	if archivo == "" {
+		archivo = archivoCheckpointPorDefecto
+	}
+
+	checkpoint := tomarCheckpoint()
+	if err := escribirCheckpoint(archivo, checkpoint); err != nil {
+		slog.Error("No se pudo guardar el checkpoint", slog.String("archivo", archivo), slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	hilos := len(checkpoint.HilosReady) + len(checkpoint.HilosExec) + len(checkpoint.HilosBlock)
+	log.Printf("## Se guarda el checkpoint en %s - Procesos: %d - Hilos: %d ##", archivo, len(checkpoint.ProcesosInicializados), hilos)
+
+	respuesta, err := json.Marshal(CheckpointResponse{Archivo: archivo, Version: checkpoint.Version})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(respuesta)
+}
+
+// tomarCheckpoint toma las colas en el mismo orden que tomarFoto, despues de
+// las esperas con tiempo porque unlockMutex toma ese mutex antes que las colas
+func tomarCheckpoint() Checkpoint {
+	mutexEsperasMutex.Lock()
+	mutexRegistrosJoin.Lock()
+	mutexProcesosSinIniciar.Lock()
+	mutexColaExitproceso.Lock()
+	mutexColaProcesosInicializados.Lock()
+	mutexColaReadyHilo.Lock()
+	mutexColaExecHilo.Lock()
+	mutexColaBlockHilo.Lock()
+	mutexColaExitHilo.Lock()
+	mutexEsperasJoin.Lock()
+
+	checkpoint := Checkpoint{
+		Version:    versionCheckpoint,
+		Fecha:      time.Now(),
+		NextPid:    nextPid,
+		NextTid:    append([]int{}, nextTid...),
+		PidInicial: pidInicial,
+		HilosReady: copiarHilos(colaReadyHilo),
+		HilosExec:  copiarHilos(colaExecHilo),
+		HilosBlock: copiarHilos(colaBlockHilo),
+		HilosExit:  copiarHilos(colaExitHilo),
+	}
+	for _, proceso := range colaProcesosSinIniciar {
+		checkpoint.ProcesosSinIniciar = append(checkpoint.ProcesosSinIniciar, ProcesoCheckpoint{
+			PCB:       pcbACheckpoint(proceso.PCB),
+			Size:      proceso.Size,
+			Path:      proceso.Path,
+			Prioridad: proceso.Prioridad,
+		})
+	}
+	for _, pcb := range colaProcesosInicializados {
+		checkpoint.ProcesosInicializados = append(checkpoint.ProcesosInicializados, pcbACheckpoint(pcb))
+	}
+	for _, pcb := range colaExitproceso {
+		checkpoint.ProcesosFinalizados = append(checkpoint.ProcesosFinalizados, pcbACheckpoint(pcb))
+	}
+	for clave, espera := range esperasMutex {
+		checkpoint.EsperasMutex = append(checkpoint.EsperasMutex, EsperaMutexCheckpoint{clave.Pid, clave.Tid, espera.mutex, espera.registro})
+	}
+	for clave, registro := range registrosJoin {
+		checkpoint.RegistrosJoin = append(checkpoint.RegistrosJoin, RegistroJoinCheckpoint{clave.Pid, clave.Tid, registro})
+	}
+	for clave, tids := range esperasJoin {
+		checkpoint.EsperasJoin = append(checkpoint.EsperasJoin, EsperaJoinCheckpoint{clave.Pid, clave.Tid, append([]int{}, tids...)})
+	}
+
+	mutexEsperasJoin.Unlock()
+	mutexColaExitHilo.Unlock()
+	mutexColaBlockHilo.Unlock()
+	mutexColaExecHilo.Unlock()
+	mutexColaReadyHilo.Unlock()
+	mutexColaProcesosInicializados.Unlock()
+	mutexColaExitproceso.Unlock()
+	mutexProcesosSinIniciar.Unlock()
+	mutexRegistrosJoin.Unlock()
+	mutexEsperasMutex.Unlock()
+
+	// El resto del estado se toma despues de soltar las colas, cada parte con su
+	// mutex. Lo que quede desfasado con las colas se descarta al reconciliar.
+	tomarEstadoSyscalls(&checkpoint)
+	planificador.exportarEstado(&checkpoint)
+	checkpoint.Cpus = cpusRegistradas()
+	return checkpoint
+}
+
+func tomarEstadoSyscalls(checkpoint *Checkpoint) {
+	mutexArbolProcesos.Lock()
+	for pid, nodo := range arbolProcesos {
+		checkpoint.Arbol = append(checkpoint.Arbol, NodoProcesoCheckpoint{pid, nodo.padre, nodo.finalizado, nodo.estado})
+	}
+	for _, espera := range esperasProceso {
+		checkpoint.EsperasProceso = append(checkpoint.EsperasProceso, EsperaProcesoCheckpoint{espera.hilo, espera.hijo, espera.registro})
+	}
+	mutexArbolProcesos.Unlock()
+
+	mutexHilosFinalizados.Lock()
+	for clave, codigo := range hilosFinalizados {
+		checkpoint.HilosFinalizados = append(checkpoint.HilosFinalizados, HiloFinalizadoCheckpoint{clave.Pid, clave.Tid, codigo})
+	}
+	mutexHilosFinalizados.Unlock()
+
+	mutexColasMensajes.Lock()
+	for _, cola := range colasMensajes {
+		estado := ColaMensajesCheckpoint{Nombre: cola.Nombre, Capacidad: cola.Capacidad, Mensajes: append([]uint32{}, cola.mensajes...)}
+		for _, emisor := range cola.emisores {
+			estado.Emisores = append(estado.Emisores, EnvioCheckpoint{emisor.hilo, emisor.valor})
+		}
+		for _, receptor := range cola.receptores {
+			estado.Receptores = append(estado.Receptores, RecepcionCheckpoint{receptor.hilo, receptor.registro})
+		}
+		for pid := range cola.procesos {
+			estado.Procesos = append(estado.Procesos, pid)
+		}
+		checkpoint.ColasMensajes = append(checkpoint.ColasMensajes, estado)
+	}
+	mutexColasMensajes.Unlock()
+
+	mutexSenales.Lock()
+	pids := make(map[int]bool)
+	for pid := range manejadoresSenal {
+		pids[pid] = true
+	}
+	for pid := range senalesPendientes {
+		pids[pid] = true
+	}
+	for pid := range pids {
+		manejadores := make(map[int]uint32)
+		for senal, pc := range manejadoresSenal[pid] {
+			manejadores[senal] = pc
+		}
+		checkpoint.Senales = append(checkpoint.Senales, SenalesCheckpoint{pid, manejadores, append([]int{}, senalesPendientes[pid]...)})
+	}
+	mutexSenales.Unlock()
+
+	tiempoReal.mutex.Lock()
+	for pid, proceso := range tiempoReal.procesos {
+		checkpoint.TiempoReal = append(checkpoint.TiempoReal, TiempoRealCheckpoint{pid, proceso.parametros})
+	}
+	tiempoReal.mutex.Unlock()
+}
+
+// escribirCheckpoint escribe primero un temporal para no dejar un checkpoint a medias
+func escribirCheckpoint(archivo string, checkpoint Checkpoint) error {
+	contenido, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporal := archivo + ".tmp"
+	if err := os.WriteFile(temporal, contenido, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporal, archivo)
+}
+
+// archivoRestauracion devuelve el archivo indicado con --restore, si se arranco en modo restauracion
+func archivoRestauracion() (string, bool) {
+	for i := 2; i < len(os.Args)-1; i++ {
+		if os.Args[i] == "--restore" {
+			return os.Args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func restaurarCheckpoint(archivo string) error {
+	contenido, err := os.ReadFile(archivo)
+	if err != nil {
+		return err
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(contenido, &checkpoint); err != nil {
+		return err
+	}
+	if checkpoint.Version != versionCheckpoint {
+		return fmt.Errorf("version de checkpoint %d no soportada, se esperaba %d", checkpoint.Version, versionCheckpoint)
+	}
+
+	// Las CPUs dejan de ejecutar los hilos del kernel anterior antes de leer
+	// los contextos de memoria y de volver a despachar
+	for _, cpu := range checkpoint.Cpus {
+		agregarCpu(cpu.Ip, cpu.Puerto)
+	}
+	reiniciarCpus()
+
+	vivos, err := consultarHilosVivos()
+	if err != nil {
+		return fmt.Errorf("no se pudo consultar a memoria: %w", err)
+	}
+
+	estado := checkpoint.estado()
+	estado.reconciliar(vivos, checkpoint.EsperasMutex, checkpoint.RegistrosJoin)
+
+	nextPid = checkpoint.NextPid
+	nextTid = checkpoint.NextTid
+	pidInicial = checkpoint.PidInicial
+
+	colaProcesosSinIniciar = estado.sinIniciar
+	colaProcesosInicializados = estado.inicializados
+	colaExitproceso = estado.finalizados
+	colaReadyHilo = estado.ready
+	colaExecHilo = nil
+	colaBlockHilo = estado.block
+	colaExitHilo = estado.exit
+
+	for _, registro := range checkpoint.RegistrosJoin {
+		if estado.estaBloqueado(registro.Pid, registro.Tid) {
+			registrosJoin[claveHilo{registro.Pid, registro.Tid}] = registro.Registro
+		}
+	}
+	estado.restaurarEstadoSyscalls(checkpoint)
+	planificador.importarEstado(checkpoint)
+	for _, tcb := range estado.ready {
+		registrarIngresoReady(tcb)
+		tiempoReal.liberar(tcb)
+	}
+	estado.finalizarPerdidos()
+
+	log.Printf("## Se restaura el checkpoint %s del %s - Procesos: %d - Hilos en READY: %d - Hilos en BLOCK: %d ##",
+		archivo, checkpoint.Fecha.Format(time.RFC3339), len(estado.inicializados), len(estado.ready), len(estado.block))
+
+	if len(estado.ready) > 0 {
+		planificador.notificar(Evento{Tipo: EventoHiloReady, Hilo: estado.ready[0]})
+	}
+	admitirProcesos()
+	return nil
+}
+
+func (p *Planificador) exportarEstado(checkpoint *Checkpoint) {
+	checkpoint.Algoritmo = p.algoritmo.Nombre()
+
+	algoritmo, ok := p.algoritmo.(schedulerConCheckpoint)
+	if !ok {
+		return
+	}
+	estado, err := algoritmo.Exportar()
+	if err != nil {
+		slog.Error("No se pudo guardar el estado del algoritmo de planificacion", slog.String("algoritmo", checkpoint.Algoritmo), slog.Any("error", err))
+		return
+	}
+	checkpoint.EstadoAlgoritmo = estado
+}
+
+func (p *Planificador) importarEstado(checkpoint Checkpoint) {
+	if len(checkpoint.EstadoAlgoritmo) == 0 {
+		return
+	}
+	if checkpoint.Algoritmo != p.algoritmo.Nombre() {
+		slog.Warn("El checkpoint es de otro algoritmo de planificacion, su estado se descarta", slog.String("checkpoint", checkpoint.Algoritmo), slog.String("algoritmo", p.algoritmo.Nombre()))
+		return
+	}
+	algoritmo, ok := p.algoritmo.(schedulerConCheckpoint)
+	if !ok {
+		return
+	}
+	if err := algoritmo.Importar(checkpoint.EstadoAlgoritmo); err != nil {
+		slog.Warn("No se pudo restaurar el estado del algoritmo de planificacion, arranca de cero", slog.String("algoritmo", checkpoint.Algoritmo), slog.Any("error", err))
+	}
+}
+
+func consultarHilosVivos() (hilosVivosMemoria, error) {
+	var vivos hilosVivosMemoria
+
+	url := fmt.Sprintf("http://%s:%d/hilos", ConfigKernel.IpMemoria, ConfigKernel.PuertoMemoria)
+	resp, err := http.Get(url)
+	if err != nil {
+		return vivos, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return vivos, fmt.Errorf("memoria respondio %d", resp.StatusCode)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&vivos)
+	return vivos, err
+}
+
+/*---------- CONVERSIONES ----------*/
+
+func copiarHilos(cola []TCB) []TCB {
+	return append(make([]TCB, 0, len(cola)), cola...)
+}
+
+func pcbACheckpoint(pcb PCB) PCBCheckpoint {
+	checkpoint := PCBCheckpoint{Pid: pcb.Pid, Tid: append([]int{}, pcb.Tid...)}
+	for _, mutex := range pcb.Mutex {
+		checkpoint.Mutex = append(checkpoint.Mutex, MutexCheckpoint{mutex.Nombre, mutex.Bloqueado, mutex.HiloUsando, copiarHilos(mutex.colaBloqueados)})
+	}
+	for _, semaforo := range pcb.Semaforos {
+		checkpoint.Semaforos = append(checkpoint.Semaforos, SemaforoCheckpoint{semaforo.Nombre, semaforo.Valor, copiarHilos(semaforo.colaBloqueados)})
+	}
+	for _, condicion := range pcb.Condiciones {
+		estado := CondicionCheckpoint{Nombre: condicion.Nombre}
+		for _, espera := range condicion.colaBloqueados {
+			estado.ColaBloqueados = append(estado.ColaBloqueados, EsperaCondicionCheckpoint{espera.hilo, espera.mutex})
+		}
+		checkpoint.Condiciones = append(checkpoint.Condiciones, estado)
+	}
+	return checkpoint
+}
+
+func (c PCBCheckpoint) pcb() PCB {
+	pcb := PCB{
+		Pid:         c.Pid,
+		Tid:         append([]int{}, c.Tid...),
+		Mutex:       []Mutex{},
+		Semaforos:   []Semaforo{},
+		Condiciones: []Condicion{},
+	}
+	for _, mutex := range c.Mutex {
+		pcb.Mutex = append(pcb.Mutex, Mutex{mutex.Nombre, mutex.Bloqueado, mutex.HiloUsando, mutex.ColaBloqueados})
+	}
+	for _, semaforo := range c.Semaforos {
+		pcb.Semaforos = append(pcb.Semaforos, Semaforo{semaforo.Nombre, semaforo.Valor, semaforo.ColaBloqueados})
+	}
+	for _, condicion := range c.Condiciones {
+		restaurada := Condicion{Nombre: condicion.Nombre}
+		for _, espera := range condicion.ColaBloqueados {
+			restaurada.colaBloqueados = append(restaurada.colaBloqueados, esperaCondicion{espera.Hilo, espera.Mutex})
+		}
+		pcb.Condiciones = append(pcb.Condiciones, restaurada)
+	}
+	return pcb
+}
+
+/*---------- RECONCILIACION CON MEMORIA ----------*/
+
+// estadoRestaurado son las colas del checkpoint mientras se reconcilian, antes de instalarlas
+type estadoRestaurado struct {
+	sinIniciar    []Proceso
+	inicializados []PCB
+	finalizados   []PCB
+	ready         []TCB
+	exec          []TCB
+	block         []TCB
+	exit          []TCB
+
+	esperasJoin      map[claveHilo][]int
+	esperasProceso   []EsperaProcesoCheckpoint
+	colasMensajes    []ColaMensajesCheckpoint
+	procesosPerdidos []int // procesos que memoria ya no tiene, se finalizan al instalar el estado
+	hilosPerdidos    []TCB // hilos que memoria ya no tiene, de procesos que siguen
+}
+
+func (c Checkpoint) estado() *estadoRestaurado {
+	estado := &estadoRestaurado{
+		ready:          c.HilosReady,
+		exec:           c.HilosExec,
+		block:          c.HilosBlock,
+		exit:           c.HilosExit,
+		esperasJoin:    make(map[claveHilo][]int),
+		esperasProceso: c.EsperasProceso,
+		colasMensajes:  c.ColasMensajes,
+	}
+	for _, espera := range c.EsperasJoin {
+		esperado := claveHilo{espera.Pid, espera.Tid}
+		estado.esperasJoin[esperado] = append(estado.esperasJoin[esperado], espera.Hilos...)
+	}
+	for _, proceso := range c.ProcesosSinIniciar {
+		estado.sinIniciar = append(estado.sinIniciar, Proceso{proceso.PCB.pcb(), proceso.Size, proceso.Path, proceso.Prioridad})
+	}
+	for _, pcb := range c.ProcesosInicializados {
+		estado.inicializados = append(estado.inicializados, pcb.pcb())
+	}
+	for _, pcb := range c.ProcesosFinalizados {
+		estado.finalizados = append(estado.finalizados, pcb.pcb())
+	}
+	return estado
+}
+
+func (e *estadoRestaurado) reconciliar(vivos hilosVivosMemoria, esperas []EsperaMutexCheckpoint, registros []RegistroJoinCheckpoint) {
+	procesosEnMemoria := make(map[int]bool)
+	for _, pid := range vivos.Procesos {
+		procesosEnMemoria[pid] = true
+	}
+	hilosEnMemoria := make(map[claveHilo]bool)
+	for _, hilo := range vivos.Hilos {
+		hilosEnMemoria[claveHilo{hilo.Pid, hilo.Tid}] = true
+	}
+
+	// Procesos que memoria ya no tiene
+	var inicializados []PCB
+	for _, pcb := range e.inicializados {
+		if procesosEnMemoria[pcb.Pid] {
+			inicializados = append(inicializados, pcb)
+			continue
+		}
+		slog.Warn("El proceso del checkpoint no existe en memoria, pasa a EXIT", slog.Int("pid", pcb.Pid))
+		for _, tcb := range e.hilosDe(pcb.Pid) {
+			e.quitarHilo(tcb)
+			e.exit = append(e.exit, tcb)
+		}
+		e.finalizados = append(e.finalizados, pcb)
+		e.procesosPerdidos = append(e.procesosPerdidos, pcb.Pid)
+	}
+	e.inicializados = inicializados
+
+	// Las esperas con tiempo se dan por vencidas, el timer se perdio con el kernel
+	for _, espera := range esperas {
+		i := e.indiceProceso(espera.Pid)
+		if i < 0 || !hilosEnMemoria[claveHilo{espera.Pid, espera.Tid}] {
+			continue
+		}
+		if j, existe := buscarMutex(e.inicializados[i], espera.Mutex); existe {
+			mutex := &e.inicializados[i].Mutex[j]
+			mutex.colaBloqueados = eliminarHiloCola(mutex.colaBloqueados, TCB{Pid: espera.Pid, Tid: espera.Tid})
+		}
+		log.Printf("## (<PID %d>:<TID %d>) - Se vence la espera del mutex %s ##", espera.Pid, espera.Tid, espera.Mutex)
+		escribirRegistro(TCB{Pid: espera.Pid, Tid: espera.Tid}, espera.Registro, 0)
+	}
+
+	// Hilos que memoria ya no tiene
+	for _, cola := range [][]TCB{e.ready, e.exec, e.block} {
+		for _, tcb := range append([]TCB(nil), cola...) {
+			if e.indiceProceso(tcb.Pid) >= 0 && !hilosEnMemoria[claveHilo{tcb.Pid, tcb.Tid}] {
+				slog.Warn("El hilo del checkpoint no existe en memoria, pasa a EXIT", slog.Int("pid", tcb.Pid), slog.Int("tid", tcb.Tid))
+				e.descartarHilo(tcb)
+			}
+		}
+	}
+
+	// La CPU perdio los hilos que ejecutaba
+	for _, tcb := range e.exec {
+		log.Printf("## (<PID %d>:<TID %d>) Se restaura el Hilo que estaba en EXEC - Estado: READY", tcb.Pid, tcb.Tid)
+		e.ready = append(e.ready, tcb)
+	}
+	e.exec = nil
+
+	// Las esperas de colas de mensajes y PROCESS_WAIT solo siguen si el hilo sigue bloqueado
+	e.descartarEsperasDesfasadas()
+
+	// Hilos bloqueados por una espera que no se guarda en el checkpoint. Si
+	// esperaban con THREAD_JOIN a un hilo que se perdio, el join termina como
+	// si lo hubieran cancelado.
+	esperando := e.hilosEsperando()
+	registrosPendientes := make(map[claveHilo]string)
+	for _, registro := range registros {
+		registrosPendientes[claveHilo{registro.Pid, registro.Tid}] = registro.Registro
+	}
+	var block []TCB
+	for _, tcb := range e.block {
+		if esperando[claveHilo{tcb.Pid, tcb.Tid}] {
+			block = append(block, tcb)
+			continue
+		}
+		if registro, ok := registrosPendientes[claveHilo{tcb.Pid, tcb.Tid}]; ok {
+			escribirRegistro(tcb, registro, CodigoSalidaCancelado)
+		}
+		log.Printf("## (<PID %d>:<TID %d>) Se restaura el Hilo bloqueado sin espera pendiente - Estado: READY", tcb.Pid, tcb.Tid)
+		e.ready = append(e.ready, tcb)
+	}
+	e.block = block
+
+	// Lo que memoria tiene y el kernel no
+	for _, pid := range vivos.Procesos {
+		if e.indiceProceso(pid) < 0 {
+			slog.Warn("Memoria tiene un proceso que no esta en el checkpoint, se finaliza", slog.Int("pid", pid))
+			enviarProcesoFinalizadoAMemoria(PCB{Pid: pid})
+		}
+	}
+	for _, hilo := range vivos.Hilos {
+		i := e.indiceProceso(hilo.Pid)
+		if i >= 0 && !contieneTid(e.inicializados[i].Tid, hilo.Tid) {
+			slog.Warn("Memoria tiene un hilo que no esta en el checkpoint, se finaliza", slog.Int("pid", hilo.Pid), slog.Int("tid", hilo.Tid))
+			enviarHiloFinalizadoAMemoria(TCB{Pid: hilo.Pid, Tid: hilo.Tid})
+		}
+	}
+}
+
+// descartarHilo pasa el hilo a EXIT y lo saca de las colas de espera de su
+// proceso. Los mutex que tenia pasan al primero que los espera.
+func (e *estadoRestaurado) descartarHilo(tcb TCB) {
+	e.quitarHilo(tcb)
+	e.exit = append(e.exit, tcb)
+	e.hilosPerdidos = append(e.hilosPerdidos, tcb)
+
+	pcb := &e.inicializados[e.indiceProceso(tcb.Pid)]
+	pcb.Tid = removeTid(pcb.Tid, tcb.Tid)
+	for i := range pcb.Mutex {
+		mutex := &pcb.Mutex[i]
+		mutex.colaBloqueados = eliminarHiloCola(mutex.colaBloqueados, tcb)
+		if mutex.HiloUsando != tcb.Tid {
+			continue
+		}
+		if len(mutex.colaBloqueados) > 0 {
+			mutex.HiloUsando = mutex.colaBloqueados[0].Tid
+			mutex.colaBloqueados = mutex.colaBloqueados[1:]
+		} else {
+			mutex.Bloqueado = false
+			mutex.HiloUsando = -1
+		}
+	}
+	for i := range pcb.Semaforos {
+		pcb.Semaforos[i].colaBloqueados = eliminarHiloCola(pcb.Semaforos[i].colaBloqueados, tcb)
+	}
+	for i := range pcb.Condiciones {
+		condicion := &pcb.Condiciones[i]
+		for j, espera := range condicion.colaBloqueados {
+			if espera.hilo.Tid == tcb.Tid {
+				condicion.colaBloqueados = append(condicion.colaBloqueados[:j], condicion.colaBloqueados[j+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// hilosEsperando son los hilos que siguen en la cola de un mutex, semaforo o
+// condicion, o que esperan con THREAD_JOIN a un hilo que no finalizo
+func (e *estadoRestaurado) hilosEsperando() map[claveHilo]bool {
+	esperando := make(map[claveHilo]bool)
+	for _, pcb := range e.inicializados {
+		for _, mutex := range pcb.Mutex {
+			for _, tcb := range mutex.colaBloqueados {
+				esperando[claveHilo{tcb.Pid, tcb.Tid}] = true
+			}
+		}
+		for _, semaforo := range pcb.Semaforos {
+			for _, tcb := range semaforo.colaBloqueados {
+				esperando[claveHilo{tcb.Pid, tcb.Tid}] = true
+			}
+		}
+		for _, condicion := range pcb.Condiciones {
+			for _, espera := range condicion.colaBloqueados {
+				esperando[claveHilo{espera.hilo.Pid, espera.hilo.Tid}] = true
+			}
+		}
+	}
+	for _, cola := range [][]TCB{e.ready, e.exec, e.block} {
+		for _, tcb := range cola {
+			for _, tid := range e.esperasJoin[claveHilo{tcb.Pid, tcb.Tid}] {
+				esperando[claveHilo{tcb.Pid, tid}] = true
+			}
+		}
+	}
+	for _, espera := range e.esperasProceso {
+		esperando[claveHilo{espera.Hilo.Pid, espera.Hilo.Tid}] = true
+	}
+	for _, cola := range e.colasMensajes {
+		for _, emisor := range cola.Emisores {
+			esperando[claveHilo{emisor.Hilo.Pid, emisor.Hilo.Tid}] = true
+		}
+		for _, receptor := range cola.Receptores {
+			esperando[claveHilo{receptor.Hilo.Pid, receptor.Hilo.Tid}] = true
+		}
+	}
+	return esperando
+}
+
+// descartarEsperasDesfasadas saca de las colas de mensajes y de PROCESS_WAIT a
+// los hilos que no estan en BLOCK: se perdieron, o se tomaron en otro estado
+// porque esas esperas se guardan despues que las colas de hilos
+func (e *estadoRestaurado) descartarEsperasDesfasadas() {
+	var esperasProceso []EsperaProcesoCheckpoint
+	for _, espera := range e.esperasProceso {
+		if e.estaBloqueado(espera.Hilo.Pid, espera.Hilo.Tid) {
+			esperasProceso = append(esperasProceso, espera)
+		}
+	}
+	e.esperasProceso = esperasProceso
+
+	for i := range e.colasMensajes {
+		cola := &e.colasMensajes[i]
+		var emisores []EnvioCheckpoint
+		for _, emisor := range cola.Emisores {
+			if e.estaBloqueado(emisor.Hilo.Pid, emisor.Hilo.Tid) {
+				emisores = append(emisores, emisor)
+			}
+		}
+		var receptores []RecepcionCheckpoint
+		for _, receptor := range cola.Receptores {
+			if e.estaBloqueado(receptor.Hilo.Pid, receptor.Hilo.Tid) {
+				receptores = append(receptores, receptor)
+			}
+		}
+		cola.Emisores = emisores
+		cola.Receptores = receptores
+	}
+}
+
+// restaurarEstadoSyscalls instala el arbol de procesos, los joins, los codigos
+// de salida, las colas de mensajes, las señales y los procesos de tiempo real
+// del checkpoint. Se llama con las colas de hilos ya instaladas.
+func (e *estadoRestaurado) restaurarEstadoSyscalls(checkpoint Checkpoint) {
+	for esperado, tids := range e.esperasJoin {
+		if _, err := buscarPorPidYTid(e.ready, esperado.Pid, esperado.Tid); err != nil && !e.estaBloqueado(esperado.Pid, esperado.Tid) {
+			continue
+		}
+		for _, tid := range tids {
+			if e.estaBloqueado(esperado.Pid, tid) {
+				esperasJoin[esperado] = append(esperasJoin[esperado], tid)
+			}
+		}
+	}
+	for _, nodo := range checkpoint.Arbol {
+		arbolProcesos[nodo.Pid] = &nodoProceso{padre: nodo.Padre, finalizado: nodo.Finalizado, estado: nodo.Estado}
+	}
+	for _, espera := range e.esperasProceso {
+		esperasProceso[claveHilo{espera.Hilo.Pid, espera.Hilo.Tid}] = esperaProceso{hilo: espera.Hilo, hijo: espera.Hijo, registro: espera.Registro}
+	}
+	for _, hilo := range checkpoint.HilosFinalizados {
+		hilosFinalizados[claveHilo{hilo.Pid, hilo.Tid}] = hilo.Codigo
+	}
+	for _, estado := range e.colasMensajes {
+		cola := &ColaMensajes{Nombre: estado.Nombre, Capacidad: estado.Capacidad, mensajes: estado.Mensajes, procesos: make(map[int]bool)}
+		for _, emisor := range estado.Emisores {
+			cola.emisores = append(cola.emisores, envioBloqueado{emisor.Hilo, emisor.Valor})
+		}
+		for _, receptor := range estado.Receptores {
+			cola.receptores = append(cola.receptores, recepcionBloqueada{receptor.Hilo, receptor.Registro})
+		}
+		for _, pid := range estado.Procesos {
+			cola.procesos[pid] = true
+		}
+		colasMensajes[cola.Nombre] = cola
+	}
+	for _, senales := range checkpoint.Senales {
+		manejadoresSenal[senales.Pid] = senales.Manejadores
+		senalesPendientes[senales.Pid] = senales.Pendientes
+	}
+	for _, proceso := range checkpoint.TiempoReal {
+		parametros, err := tiempoReal.reservar(proceso.Parametros)
+		if err != nil {
+			slog.Warn("No se pudo restaurar el proceso de tiempo real", slog.Int("pid", proceso.Pid), slog.Any("error", err))
+			continue
+		}
+		tiempoReal.registrar(proceso.Pid, parametros)
+	}
+}
+
+// finalizarPerdidos limpia lo que quedo de los hilos y procesos que memoria ya
+// no tenia, por el mismo camino que exitHilo y exitProcess
+func (e *estadoRestaurado) finalizarPerdidos() {
+	for _, tcb := range e.hilosPerdidos {
+		registrarFinHilo(tcb, CodigoSalidaCancelado)
+	}
+	for _, tcb := range e.exit {
+		planificador.hiloFinalizado(tcb)
+	}
+	for _, pid := range e.procesosPerdidos {
+		tiempoReal.quitarProceso(pid)
+		olvidarHilosFinalizados(pid)
+		olvidarSenales(pid)
+		liberarColasDeMensajes(pid)
+		finalizarEnArbol(pid, EstadoSalidaFinalizado)
+	}
+}
+
+func (e *estadoRestaurado) hilosDe(pid int) []TCB {
+	var hilos []TCB
+	for _, cola := range [][]TCB{e.ready, e.exec, e.block} {
+		for _, tcb := range cola {
+			if tcb.Pid == pid {
+				hilos = append(hilos, tcb)
+			}
+		}
+	}
+	return hilos
+}
+
+func (e *estadoRestaurado) quitarHilo(tcb TCB) {
+	e.ready = eliminarHiloCola(e.ready, tcb)
+	e.exec = eliminarHiloCola(e.exec, tcb)
+	e.block = eliminarHiloCola(e.block, tcb)
+}
+
+func (e *estadoRestaurado) estaBloqueado(pid int, tid int) bool {
+	_, err := buscarPorPidYTid(e.block, pid, tid)
+	return err == nil
+}
+
+func (e *estadoRestaurado) indiceProceso(pid int) int {
+	for i, pcb := range e.inicializados {
+		if pcb.Pid == pid {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+)
+
+/*---------------------- VARIABLES DE CONDICION ----------------------*/
+
+// Condicion de un proceso. COND_WAIT libera el mutex y bloquea al hilo en la
+// cola de la condicion en la misma syscall; al despertarlo con COND_SIGNAL o
+// COND_BROADCAST el hilo vuelve a pedir el mutex con lockMutex, asi que puede
+// pasar de la cola de la condicion a la del mutex.
+type Condicion struct {
+	Nombre         string
+	colaBloqueados []esperaCondicion
+}
+
+type esperaCondicion struct {
+	hilo  TCB
+	mutex string // mutex que el hilo libero y debe recuperar al despertar
+}
+
+type CondicionRequest struct {
+	Pid       int    `json:"pid"`
+	Tid       int    `json:"tid"`
+	Condicion string `json:"condicion"`
+	Mutex     string `json:"mutex"`
+}
+
+func CrearCondicion(w http.ResponseWriter, r *http.Request) {
+	var condicion CondicionRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&condicion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := condicion.Pid
+	tid := condicion.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <COND_CREATE> ##", pid, tid)
+
+	pcb, _ := getPCB(pid)
+	if _, existe := buscarCondicion(pcb, condicion.Condicion); existe {
+		slog.Warn("La condicion ya existe", slog.String("condicion", condicion.Condicion))
+	} else {
+		pcb.Condiciones = append(pcb.Condiciones, condicionCreate(condicion.Condicion))
+		actualizarPCB(pcb)
+	}
+
+	tcbActual := getTCB(pid, tid)
+	enviarTCBCpu(tcbActual)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func EsperarCondicion(w http.ResponseWriter, r *http.Request) {
+	var condicion CondicionRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&condicion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := condicion.Pid
+	tid := condicion.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <COND_WAIT> ##", pid, tid)
+
+	proceso, _ := getPCB(pid)
+	hiloSolicitante := getTCB(pid, tid)
+
+	waitCondicion(proceso, hiloSolicitante, condicion.Condicion, condicion.Mutex)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func SenalarCondicion(w http.ResponseWriter, r *http.Request) {
+	despertarCondicion(w, r, "COND_SIGNAL", false)
+}
+
+func DifundirCondicion(w http.ResponseWriter, r *http.Request) {
+	despertarCondicion(w, r, "COND_BROADCAST", true)
+}
+
+func despertarCondicion(w http.ResponseWriter, r *http.Request, syscall string, todos bool) {
+	var condicion CondicionRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&condicion)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := condicion.Pid
+	tid := condicion.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <%s> ##", pid, tid, syscall)
+
+	hiloSolicitante := getTCB(pid, tid)
+
+	for signalCondicion(pid, condicion.Condicion) {
+		if !todos {
+			break
+		}
+	}
+
+	enviarTCBCpu(hiloSolicitante)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func waitCondicion(proceso PCB, hiloSolicitante TCB, condicionNombre string, mutexNombre string) {
+	i, existe := buscarCondicion(proceso, condicionNombre)
+	if !existe {
+		slog.Warn("La condicion no existe", slog.String("condicion", condicionNombre))
+		enviarTCBCpu(hiloSolicitante)
+		return
+	}
+	j, existe := buscarMutex(proceso, mutexNombre)
+	if !existe || proceso.Mutex[j].HiloUsando != hiloSolicitante.Tid {
+		slog.Warn("El hilo solicitante no tiene asignado al mutex", slog.String("mutex", mutexNombre))
+		enviarTCBCpu(hiloSolicitante)
+		return
+	}
+
+	condicion := proceso.Condiciones[i]
+	condicion.colaBloqueados = append(condicion.colaBloqueados, esperaCondicion{hilo: hiloSolicitante, mutex: mutexNombre})
+	proceso.Condiciones[i] = condicion
+	actualizarPCB(proceso)
+
+	quitarExec(hiloSolicitante)
+	encolarBlock(hiloSolicitante, "CONDICION")
+
+	unlockMutex(proceso, hiloSolicitante, mutexNombre)
+}
+
+// signalCondicion despierta al primer hilo que espera la condicion, devuelve false si no habia ninguno
+func signalCondicion(pid int, condicionNombre string) bool {
+	proceso, _ := getPCB(pid)
+
+	i, existe := buscarCondicion(proceso, condicionNombre)
+	if !existe {
+		slog.Warn("La condicion no existe", slog.String("condicion", condicionNombre))
+		return false
+	}
+	condicion := proceso.Condiciones[i]
+	if len(condicion.colaBloqueados) == 0 {
+		return false
+	}
+
+	espera := condicion.colaBloqueados[0]
+	condicion.colaBloqueados = condicion.colaBloqueados[1:]
+	proceso.Condiciones[i] = condicion
+	actualizarPCB(proceso)
+
+	// El hilo despierta pidiendo el mutex: si esta libre pasa a READY con el
+	// mutex asignado, si no queda bloqueado en la cola del mutex
+	quitarBlock(espera.hilo)
+	if j, existe := buscarMutex(proceso, espera.mutex); existe && !proceso.Mutex[j].Bloqueado {
+		encolarReady(espera.hilo)
+	}
+	lockMutex(proceso, espera.hilo, espera.mutex)
+	return true
+}
+
+func condicionCreate(nombreCondicion string) Condicion {
+	return Condicion{
+		Nombre:         nombreCondicion,
+		colaBloqueados: []esperaCondicion{},
+	}
+}
+
+func buscarCondicion(proceso PCB, condicionNombre string) (int, bool) {
+	for i, condicion := range proceso.Condiciones {
+		if condicion.Nombre == condicionNombre {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// quitarDeColasDeCondiciones saca al hilo de las colas de espera de las condiciones del proceso
+func quitarDeColasDeCondiciones(proceso PCB, hilo TCB) {
+	for i := range proceso.Condiciones {
+		cola := proceso.Condiciones[i].colaBloqueados
+		for j, espera := range cola {
+			if espera.hilo.Pid == hilo.Pid && espera.hilo.Tid == hilo.Tid {
+				proceso.Condiciones[i].colaBloqueados = append(cola[:j], cola[j+1:]...)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/*---------------------- CONSOLA DEL KERNEL ----------------------*/
+
+// IniciarConsola lee comandos del operador por stdin hasta que se cierra la entrada
+func IniciarConsola() {
+	log.Printf("## Consola del Kernel iniciada ##")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		linea := strings.Fields(scanner.Text())
+		if len(linea) == 0 {
+			continue
+		}
+		if err := ejecutarComando(strings.ToUpper(linea[0]), linea[1:]); err != nil {
+			log.Printf("## Consola - %s: %s ##", linea[0], err.Error())
+		}
+	}
+}
+
+func ejecutarComando(comando string, parametros []string) error {
+	switch comando {
+	case "INICIAR_PROCESO":
+		if len(parametros) != 3 {
+			return fmt.Errorf("uso: INICIAR_PROCESO <path> <size> <prioridad>")
+		}
+		size, errSize := strconv.Atoi(parametros[1])
+		prioridad, errPrioridad := strconv.Atoi(parametros[2])
+		if errSize != nil || errPrioridad != nil {
+			return fmt.Errorf("size y prioridad deben ser numeros")
+		}
+		pid := iniciarProceso(parametros[0], size, prioridad, sinPadre)
+		log.Printf("## Consola - Se inicia el proceso %d ##", pid)
+
+	case "FINALIZAR_PROCESO":
+		if len(parametros) != 1 {
+			return fmt.Errorf("uso: FINALIZAR_PROCESO <pid>")
+		}
+		pid, err := strconv.Atoi(parametros[0])
+		if err != nil {
+			return fmt.Errorf("pid invalido")
+		}
+		return matarProceso(pid, EstadoSalidaFinalizado)
+
+	case "LISTAR_PROCESOS":
+		for _, proceso := range tomarFoto().procesos {
+			log.Printf("## Consola - (<PID %d>) Estado: %s - Hilos: %v ##", proceso.Pid, proceso.Estado, proceso.Tids)
+		}
+
+	case "LISTAR_HILOS":
+		for _, hilo := range tomarFoto().hilos {
+			log.Printf("## Consola - (<PID %d>:<TID %d>) Estado: %s - Prioridad: %d ##", hilo.Pid, hilo.Tid, hilo.Estado, hilo.Prioridad)
+		}
+
+	case "DETENER_PLANIFICACION":
+		if !planificador.detener() {
+			return fmt.Errorf("la planificacion ya esta detenida")
+		}
+		log.Printf("## Se detiene la planificacion ##")
+
+	case "INICIAR_PLANIFICACION":
+		if !planificador.reanudar() {
+			return fmt.Errorf("la planificacion no esta detenida")
+		}
+		log.Printf("## Se reanuda la planificacion ##")
+
+	default:
+		return fmt.Errorf("comando desconocido")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sisoputnfrba/tp-golang/kernel/globals"
+)
+
+/*---------------------- POOL DE CPUS ----------------------*/
+
+type Cpu struct {
+	Id     int
+	Ip     string
+	Puerto int
+
+	// Estado del despacho actual, lo modifica solo el planificador
+	ocupada           bool
+	hilo              TCB
+	inicioRafaga      time.Time
+	despacho          int // se incrementa cada vez que un hilo entra a esta CPU
+	desalojoPendiente bool
+	quantumAgotado    bool
+}
+
+type CpuRequest struct {
+	Ip     string `json:"ip"`
+	Puerto int    `json:"puerto"`
+}
+
+var cpus []*Cpu
+var mutexCpus sync.Mutex
+
+// iniciarCpus carga las CPUs de la configuracion. Si no se listan CPUs se usa
+// la CPU de ip_cpu/puerto_cpu, como antes del pool.
+func iniciarCpus(config *globals.Config) {
+	if len(config.Cpus) == 0 {
+		agregarCpu(config.IpCpu, config.PuertoCpu)
+		return
+	}
+	for _, cpu := range config.Cpus {
+		agregarCpu(cpu.Ip, cpu.Puerto)
+	}
+}
+
+func agregarCpu(ip string, puerto int) (*Cpu, bool) {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	for _, cpu := range cpus {
+		if cpu.Ip == ip && cpu.Puerto == puerto {
+			return cpu, false
+		}
+	}
+
+	cpu := &Cpu{Id: len(cpus), Ip: ip, Puerto: puerto}
+	cpus = append(cpus, cpu)
+	log.Printf("## Se agrega la CPU %d - IP: %s - Puerto: %d ##", cpu.Id, ip, puerto)
+	return cpu, true
+}
+
+func RegistrarCpu(w http.ResponseWriter, r *http.Request) {
+	var cpuRequest CpuRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&cpuRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if cpuRequest.Ip == "" || cpuRequest.Puerto == 0 {
+		http.Error(w, "ip y puerto son obligatorios", http.StatusBadRequest)
+		return
+	}
+
+	cpu, nueva := agregarCpu(cpuRequest.Ip, cpuRequest.Puerto)
+	if nueva {
+		planificador.notificar(Evento{Tipo: EventoCpuLibre, Cpu: cpu.Id})
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// cpusRegistradas devuelve la direccion de cada CPU del pool, para el checkpoint
+func cpusRegistradas() []CpuRequest {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	var registradas []CpuRequest
+	for _, cpu := range cpus {
+		registradas = append(registradas, CpuRequest{Ip: cpu.Ip, Puerto: cpu.Puerto})
+	}
+	return registradas
+}
+
+// reiniciarCpus le pide a cada CPU que abandone el hilo que esta ejecutando. La
+// CPU responde cuando ya no ejecuta nada, sin devolver el hilo ni guardar su contexto.
+func reiniciarCpus() {
+	mutexCpus.Lock()
+	pool := append([]*Cpu{}, cpus...)
+	mutexCpus.Unlock()
+
+	for _, cpu := range pool {
+		url := fmt.Sprintf("http://%s:%d/reiniciar", cpu.Ip, cpu.Puerto)
+		resp, err := http.Post(url, "application/json", nil)
+		if err != nil {
+			slog.Warn("No se pudo reiniciar la CPU", slog.Int("cpu", cpu.Id), slog.Any("error", err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			slog.Warn("No se pudo reiniciar la CPU", slog.Int("cpu", cpu.Id), slog.Int("status", resp.StatusCode))
+			continue
+		}
+		log.Printf("## Se reinicia la CPU %d - IP: %s - Puerto: %d ##", cpu.Id, cpu.Ip, cpu.Puerto)
+	}
+}
+
+func getCpu(id int) *Cpu {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+	return cpus[id]
+}
+
+// getCpuDelHilo devuelve la CPU que esta ejecutando al hilo
+func getCpuDelHilo(pid int, tid int) (*Cpu, error) {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	for _, cpu := range cpus {
+		if cpu.ocupada && cpu.hilo.Pid == pid && cpu.hilo.Tid == tid {
+			return cpu, nil
+		}
+	}
+	return nil, fmt.Errorf("el hilo (<PID:%d>:<TID:%d>) no esta asignado a ninguna CPU", pid, tid)
+}
+
+func getCpuLibre() *Cpu {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	for _, cpu := range cpus {
+		if !cpu.ocupada {
+			return cpu
+		}
+	}
+	return nil
+}
+
+// getCpusOcupadas devuelve una copia del estado de las CPUs que tienen un hilo asignado
+func getCpusOcupadas() []Cpu {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	var ocupadas []Cpu
+	for _, cpu := range cpus {
+		if cpu.ocupada {
+			ocupadas = append(ocupadas, *cpu)
+		}
+	}
+	return ocupadas
+}
+
+func ocuparCpu(cpu *Cpu, hilo TCB) int {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	cpu.ocupada = true
+	cpu.hilo = hilo
+	cpu.inicioRafaga = time.Now()
+	cpu.despacho++
+	cpu.desalojoPendiente = false
+	cpu.quantumAgotado = false
+	return cpu.despacho
+}
+
+// liberarCpu libera la CPU y devuelve el hilo que tenia asignado y como termino su rafaga
+func liberarCpu(cpu *Cpu) (TCB, Rafaga) {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	cpu.ocupada = false
+	rafaga := Rafaga{
+		Duracion:     time.Since(cpu.inicioRafaga),
+		Desalojado:   cpu.desalojoPendiente,
+		AgotoQuantum: cpu.quantumAgotado,
+	}
+	return cpu.hilo, rafaga
+}
+
+// tiempoEnCpu devuelve cuanto hace que el hilo esta en la CPU en el despacho actual, 0 si no esta ejecutando
+func tiempoEnCpu(hilo TCB) time.Duration {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	for _, cpu := range cpus {
+		if cpu.ocupada && cpu.hilo.Pid == hilo.Pid && cpu.hilo.Tid == hilo.Tid {
+			return time.Since(cpu.inicioRafaga)
+		}
+	}
+	return 0
+}
+
+// marcarDesalojo registra que se pidio desalojar el despacho actual de la CPU.
+// Devuelve false si la CPU ya cambio de despacho o si ya se pidio el desalojo.
+func marcarDesalojo(cpu *Cpu, despacho int, porQuantum bool) bool {
+	mutexCpus.Lock()
+	defer mutexCpus.Unlock()
+
+	if !cpu.ocupada || cpu.despacho != despacho || cpu.desalojoPendiente {
+		return false
+	}
+	cpu.desalojoPendiente = true
+	cpu.quantumAgotado = porQuantum
+	return true
+}
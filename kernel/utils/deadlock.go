@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+/*---------------------- DETECCION DE DEADLOCK ----------------------*/
+
+// Grafo de espera: un hilo bloqueado en un mutex espera al hilo que lo tiene
+// asignado y un hilo bloqueado en un join espera al hilo al que se unio. Como
+// cada hilo bloqueado espera a un unico hilo, alcanza con seguir las aristas
+// desde el hilo que se acaba de bloquear para encontrar un ciclo.
+
+const (
+	DeadlockReportar         string = "REPORTAR"
+	DeadlockFinalizarVictima string = "FINALIZAR_VICTIMA"
+)
+
+var politicaDeadlock string
+
+func crearPoliticaDeadlock(politica string) (string, error) {
+	switch politica {
+	case "", DeadlockReportar:
+		return DeadlockReportar, nil
+	case DeadlockFinalizarVictima:
+		return DeadlockFinalizarVictima, nil
+	default:
+		return "", fmt.Errorf("politica de deadlock %s no valida", politica)
+	}
+}
+
+// armarGrafoDeEspera devuelve a que hilo espera cada hilo bloqueado y la prioridad de cada hilo
+func armarGrafoDeEspera(foto fotoKernel) (map[claveHilo]claveHilo, map[claveHilo]int) {
+	esperaA := make(map[claveHilo]claveHilo)
+	prioridades := make(map[claveHilo]int)
+
+	for _, proceso := range foto.procesos {
+		for _, mutex := range proceso.Mutex {
+			if mutex.HiloUsando < 0 {
+				continue
+			}
+			for _, bloqueado := range mutex.ColaBloqueados {
+				esperaA[claveHilo{bloqueado.Pid, bloqueado.Tid}] = claveHilo{proceso.Pid, mutex.HiloUsando}
+			}
+		}
+	}
+	for _, hilo := range foto.hilos {
+		prioridades[claveHilo{hilo.Pid, hilo.Tid}] = hilo.Prioridad
+		for _, tid := range hilo.HilosBloqueados {
+			esperaA[claveHilo{hilo.Pid, tid}] = claveHilo{hilo.Pid, hilo.Tid}
+		}
+	}
+	return esperaA, prioridades
+}
+
+// buscarCiclo devuelve el ciclo de espera que contiene al hilo, si existe
+func buscarCiclo(esperaA map[claveHilo]claveHilo, hilo claveHilo) []claveHilo {
+	ciclo := []claveHilo{hilo}
+	visitados := map[claveHilo]bool{hilo: true}
+
+	actual := hilo
+	for {
+		siguiente, espera := esperaA[actual]
+		if !espera {
+			return nil
+		}
+		if siguiente == hilo {
+			return ciclo
+		}
+		if visitados[siguiente] {
+			// se llega a un ciclo que no incluye al hilo, ya se detecto cuando se formo
+			return nil
+		}
+		visitados[siguiente] = true
+		ciclo = append(ciclo, siguiente)
+		actual = siguiente
+	}
+}
+
+// detectarDeadlock se llama cada vez que un hilo se bloquea por un mutex o un join
+func detectarDeadlock(hilo TCB) {
+	esperaA, prioridades := armarGrafoDeEspera(tomarFoto())
+
+	ciclo := buscarCiclo(esperaA, claveHilo{hilo.Pid, hilo.Tid})
+	if ciclo == nil {
+		return
+	}
+
+	var descripcion []string
+	for _, h := range append(ciclo, ciclo[0]) {
+		descripcion = append(descripcion, fmt.Sprintf("(<PID %d>:<TID %d>)", h.Pid, h.Tid))
+	}
+	log.Printf("## Deadlock detectado - Ciclo: %s ##", strings.Join(descripcion, " -> "))
+
+	if politicaDeadlock != DeadlockFinalizarVictima {
+		return
+	}
+
+	victima := elegirVictimaDeadlock(ciclo, prioridades)
+	log.Printf("## (<PID %d>:<TID %d>) Se finaliza el hilo para resolver el deadlock ##", victima.Pid, victima.Tid)
+	if err := exitHilo(victima.Pid, victima.Tid, CodigoSalidaCancelado); err != nil {
+		log.Printf("## (<PID %d>:<TID %d>) Error al finalizar el hilo victima: %s ##", victima.Pid, victima.Tid, err.Error())
+	}
+}
+
+// elegirVictimaDeadlock elige al hilo de peor prioridad (el numero mas alto);
+// a igual prioridad al de TID mas alto, para que el hilo main sea el ultimo en caer
+func elegirVictimaDeadlock(ciclo []claveHilo, prioridades map[claveHilo]int) claveHilo {
+	victima := ciclo[0]
+	for _, hilo := range ciclo[1:] {
+		if prioridades[hilo] > prioridades[victima] || (prioridades[hilo] == prioridades[victima] && hilo.Tid > victima.Tid) {
+			victima = hilo
+		}
+	}
+	return victima
+}
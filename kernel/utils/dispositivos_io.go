@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*---------------------- DISPOSITIVOS DE IO ----------------------*/
+
+// Cada dispositivo declarado en la configuracion atiende una solicitud a la vez
+// en orden de llegada; el resto de los hilos espera en la cola del dispositivo
+// (en BLOCK por "IO"). La instruccion IO sin dispositivo sigue sin contencion.
+
+type DispositivoIO struct {
+	Nombre string
+
+	mutex      sync.Mutex
+	hayPedidos *sync.Cond
+	cola       []solicitudIO
+	atendiendo *solicitudIO
+
+	atendidas    int
+	esperaTotal  time.Duration
+	esperaMaxima time.Duration
+}
+
+type solicitudIO struct {
+	hilo    TCB
+	tiempo  int // ms de uso del dispositivo
+	llegada time.Time
+}
+
+type DispositivoIOEstado struct {
+	Nombre           string       `json:"nombre"`
+	Atendiendo       *TCBRequest  `json:"atendiendo,omitempty"`
+	Cola             []TCBRequest `json:"cola"`
+	Atendidas        int          `json:"atendidas"`
+	EsperaTotalMs    int64        `json:"esperaTotalMs"`
+	EsperaPromedioMs int64        `json:"esperaPromedioMs"`
+	EsperaMaximaMs   int64        `json:"esperaMaximaMs"`
+}
+
+var dispositivosIO = make(map[string]*DispositivoIO)
+
+func crearDispositivosIO(nombres []string) (map[string]*DispositivoIO, error) {
+	dispositivos := make(map[string]*DispositivoIO)
+	for _, nombre := range nombres {
+		if nombre == "" {
+			return nil, fmt.Errorf("dispositivo de IO sin nombre")
+		}
+		if _, existe := dispositivos[nombre]; existe {
+			return nil, fmt.Errorf("dispositivo de IO %s repetido", nombre)
+		}
+		dispositivo := &DispositivoIO{Nombre: nombre}
+		dispositivo.hayPedidos = sync.NewCond(&dispositivo.mutex)
+		dispositivos[nombre] = dispositivo
+	}
+	return dispositivos, nil
+}
+
+func iniciarDispositivosIO() {
+	for _, dispositivo := range dispositivosIO {
+		go dispositivo.atender()
+	}
+}
+
+// solicitar encola el pedido del hilo, que ya tiene que estar en BLOCK
+func (d *DispositivoIO) solicitar(hilo TCB, tiempo int) {
+	d.mutex.Lock()
+	d.cola = append(d.cola, solicitudIO{hilo: hilo, tiempo: tiempo, llegada: time.Now()})
+	d.mutex.Unlock()
+	d.hayPedidos.Signal()
+}
+
+func (d *DispositivoIO) atender() {
+	for {
+		d.mutex.Lock()
+		for len(d.cola) == 0 {
+			d.hayPedidos.Wait()
+		}
+		solicitud := d.cola[0]
+		d.cola = d.cola[1:]
+		d.atendiendo = &solicitud
+		espera := time.Since(solicitud.llegada)
+		d.mutex.Unlock()
+
+		log.Printf("## (<PID %d>:<TID %d>) Inicia IO en %s - Espera en cola: %d ms ##", solicitud.hilo.Pid, solicitud.hilo.Tid, d.Nombre, espera.Milliseconds())
+		time.Sleep(time.Duration(solicitud.tiempo) * time.Millisecond)
+
+		d.mutex.Lock()
+		d.atendiendo = nil
+		d.atendidas++
+		d.esperaTotal += espera
+		if espera > d.esperaMaxima {
+			d.esperaMaxima = espera
+		}
+		d.mutex.Unlock()
+
+		log.Printf("## (<PID %d>:<TID %d>) Finaliza IO en %s ##", solicitud.hilo.Pid, solicitud.hilo.Tid, d.Nombre)
+		finalizarIO(solicitud.hilo)
+	}
+}
+
+// finalizarIO pasa el hilo a READY, salvo que haya finalizado mientras usaba el dispositivo
+func finalizarIO(hilo TCB) {
+	mutexColaBlockHilo.Lock()
+	hiloBloqueado, err := buscarPorPidYTid(colaBlockHilo, hilo.Pid, hilo.Tid)
+	mutexColaBlockHilo.Unlock()
+	if err != nil {
+		return
+	}
+	quitarBlock(hiloBloqueado)
+	encolarReady(hiloBloqueado)
+}
+
+// quitarDeColasIO saca al hilo de la cola de los dispositivos, si estaba esperando alguno
+func quitarDeColasIO(hilo TCB) {
+	for _, dispositivo := range dispositivosIO {
+		dispositivo.mutex.Lock()
+		for i, solicitud := range dispositivo.cola {
+			if solicitud.hilo.Pid == hilo.Pid && solicitud.hilo.Tid == hilo.Tid {
+				dispositivo.cola = append(dispositivo.cola[:i], dispositivo.cola[i+1:]...)
+				break
+			}
+		}
+		dispositivo.mutex.Unlock()
+	}
+}
+
+func (d *DispositivoIO) estado() DispositivoIOEstado {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	estado := DispositivoIOEstado{
+		Nombre:         d.Nombre,
+		Cola:           []TCBRequest{},
+		Atendidas:      d.atendidas,
+		EsperaTotalMs:  d.esperaTotal.Milliseconds(),
+		EsperaMaximaMs: d.esperaMaxima.Milliseconds(),
+	}
+	if d.atendidas > 0 {
+		estado.EsperaPromedioMs = estado.EsperaTotalMs / int64(d.atendidas)
+	}
+	if d.atendiendo != nil {
+		estado.Atendiendo = &TCBRequest{Pid: d.atendiendo.hilo.Pid, Tid: d.atendiendo.hilo.Tid}
+	}
+	for _, solicitud := range d.cola {
+		estado.Cola = append(estado.Cola, TCBRequest{Pid: solicitud.hilo.Pid, Tid: solicitud.hilo.Tid})
+	}
+	return estado
+}
+
+// GET /io, estado y tiempos de espera de cada dispositivo
+func ListarDispositivosIO(w http.ResponseWriter, r *http.Request) {
+	nombres := make([]string, 0, len(dispositivosIO))
+	for nombre := range dispositivosIO {
+		nombres = append(nombres, nombre)
+	}
+	sort.Strings(nombres)
+
+	dispositivos := []DispositivoIOEstado{}
+	for _, nombre := range nombres {
+		dispositivos = append(dispositivos, dispositivosIO[nombre].estado())
+	}
+	responderJSON(w, dispositivos)
+}
+
+func solicitarDispositivoIO(tcb TCB, nombre string, tiempo int) bool {
+	dispositivo, existe := dispositivosIO[nombre]
+	if !existe {
+		slog.Warn("El dispositivo de IO no existe", slog.String("dispositivo", nombre))
+		return false
+	}
+
+	quitarExec(tcb)
+	encolarBlock(tcb, "IO")
+	dispositivo.solicitar(tcb, tiempo)
+	return true
+}
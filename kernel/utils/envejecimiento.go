@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sisoputnfrba/tp-golang/kernel/globals"
+)
+
+/*---------------------- ENVEJECIMIENTO ----------------------*/
+
+// Momento en que cada hilo entro a READY. Se borra cuando el hilo sale de READY.
+var ingresosReady = make(map[claveHilo]time.Time)
+var mutexIngresosReady sync.Mutex
+
+func registrarIngresoReady(hilo TCB) {
+	mutexIngresosReady.Lock()
+	ingresosReady[claveHilo{hilo.Pid, hilo.Tid}] = time.Now()
+	mutexIngresosReady.Unlock()
+}
+
+func borrarIngresoReady(hilo TCB) {
+	mutexIngresosReady.Lock()
+	delete(ingresosReady, claveHilo{hilo.Pid, hilo.Tid})
+	mutexIngresosReady.Unlock()
+}
+
+// tiempoEnReady devuelve cuanto hace que el hilo espera en READY, 0 si no esta en READY
+func tiempoEnReady(hilo TCB) time.Duration {
+	mutexIngresosReady.Lock()
+	defer mutexIngresosReady.Unlock()
+
+	ingreso, ok := ingresosReady[claveHilo{hilo.Pid, hilo.Tid}]
+	if !ok {
+		return 0
+	}
+	return time.Since(ingreso)
+}
+
+// Envejecimiento mejora la prioridad de un hilo en incremento por cada
+// intervalo (en milisegundos) que lleva esperando en READY. Como la espera se
+// cuenta desde el ingreso a READY, la mejora se pierde cuando el hilo se despacha.
+type Envejecimiento struct {
+	intervalo  int
+	incremento int
+}
+
+func nuevoEnvejecimiento(config *globals.Config) Envejecimiento {
+	return Envejecimiento{
+		intervalo:  config.IntervaloEnvejecimiento,
+		incremento: config.IncrementoEnvejecimiento,
+	}
+}
+
+func (e Envejecimiento) habilitado() bool {
+	return e.intervalo > 0 && e.incremento > 0
+}
+
+// aplicar devuelve la prioridad efectiva del hilo partiendo de prioridad.
+// 0 es la maxima prioridad, por lo que nunca se baja de 0.
+func (e Envejecimiento) aplicar(hilo TCB, prioridad int) int {
+	if !e.habilitado() {
+		return prioridad
+	}
+
+	intervalos := int(tiempoEnReady(hilo) / (time.Duration(e.intervalo) * time.Millisecond))
+	prioridad -= intervalos * e.incremento
+	if prioridad < 0 {
+		return 0
+	}
+	return prioridad
+}
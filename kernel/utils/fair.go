@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/sisoputnfrba/tp-golang/kernel/globals"
+)
+
+/*---------- FAIR ----------*/
+
+// schedulerFair reparte la CPU en partes iguales entre procesos y, dentro de
+// cada proceso, entre sus hilos. Se lleva un tiempo virtual de ejecucion por
+// proceso y por hilo que crece mas lento cuanto mejor es la prioridad del hilo,
+// y siempre se elige al proceso con menor tiempo virtual y dentro de el al
+// hilo con menor tiempo virtual. Los procesos e hilos nuevos arrancan en el
+// minimo actual para no acaparar la CPU hasta alcanzar a los demas. Lo mismo
+// pasa con los que vuelven de un bloqueo, salvo que se les descuenta medio
+// quantum para que un hilo interactivo se despache antes que los que no se bloquean.
+type schedulerFair struct {
+	quantum int
+
+	mutex          sync.Mutex
+	procesos       map[int]*vruntimeProceso
+	minimoProcesos float64 // minimo tiempo virtual de los procesos en READY, nunca decrece
+}
+
+type vruntimeProceso struct {
+	vruntime    float64
+	hilos       map[int]float64
+	minimoHilos float64 // minimo tiempo virtual de los hilos del proceso en READY, nunca decrece
+	bloqueados  map[int]bool
+	bloqueado   bool // todos los hilos del proceso estaban bloqueados
+}
+
+// pesoBase es el peso de un hilo de prioridad 0, cada nivel de prioridad pesa un 25% menos
+const pesoBase float64 = 1024
+
+func nuevoSchedulerFair(config *globals.Config) *schedulerFair {
+	return &schedulerFair{
+		quantum:  config.Quantum,
+		procesos: make(map[int]*vruntimeProceso),
+	}
+}
+
+func pesoPrioridad(prioridad int) float64 {
+	return pesoBase / math.Pow(1.25, float64(prioridad))
+}
+
+func (*schedulerFair) Nombre() string { return "FAIR" }
+
+func (s *schedulerFair) Elegir(colaReady []TCB) TCB {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ajustarDespertados(colaReady)
+	s.actualizarMinimos(colaReady)
+
+	var elegido TCB
+	var procesoElegido *vruntimeProceso
+	for _, hilo := range colaReady {
+		proceso := s.proceso(hilo.Pid)
+		vruntimeHilo := s.vruntimeHilo(proceso, hilo.Tid)
+
+		switch {
+		case procesoElegido == nil,
+			proceso.vruntime < procesoElegido.vruntime,
+			proceso == procesoElegido && vruntimeHilo < procesoElegido.hilos[elegido.Tid]:
+			elegido = hilo
+			procesoElegido = proceso
+		}
+	}
+	return elegido
+}
+
+func (*schedulerFair) Desalojar(candidato TCB, ejecutando TCB) bool { return false }
+
+// Quantum reparte la CPU entre los hilos, sin quantum un hilo que no se bloquea nunca la suelta
+func (s *schedulerFair) Quantum(hilo TCB) int { return s.quantum }
+
+func (s *schedulerFair) FinDeRafaga(hilo TCB, rafaga Rafaga) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	proceso := s.proceso(hilo.Pid)
+	vruntimeHilo := s.vruntimeHilo(proceso, hilo.Tid)
+
+	incremento := float64(rafaga.Duracion.Milliseconds()) * pesoBase / pesoPrioridad(hilo.Prioridad)
+	proceso.hilos[hilo.Tid] = vruntimeHilo + incremento
+	proceso.vruntime += incremento
+
+	log.Printf("## (<PID %d>:<TID %d>) Tiempo virtual del hilo: %.2f - Tiempo virtual del proceso: %.2f ##", hilo.Pid, hilo.Tid, proceso.hilos[hilo.Tid], proceso.vruntime)
+}
+
+func (s *schedulerFair) Olvidar(hilo TCB) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	proceso, ok := s.procesos[hilo.Pid]
+	if !ok {
+		return
+	}
+	delete(proceso.hilos, hilo.Tid)
+	delete(proceso.bloqueados, hilo.Tid)
+	if len(proceso.hilos) == 0 {
+		delete(s.procesos, hilo.Pid)
+	}
+}
+
+type estadoFairCheckpoint struct {
+	MinimoProcesos float64                 `json:"minimoProcesos"`
+	Procesos       []procesoFairCheckpoint `json:"procesos"`
+}
+
+type procesoFairCheckpoint struct {
+	Pid         int             `json:"pid"`
+	Vruntime    float64         `json:"vruntime"`
+	Hilos       map[int]float64 `json:"hilos"`
+	MinimoHilos float64         `json:"minimoHilos"`
+	Bloqueados  []int           `json:"bloqueados"`
+	Bloqueado   bool            `json:"bloqueado"`
+}
+
+func (s *schedulerFair) Exportar() (json.RawMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	estado := estadoFairCheckpoint{MinimoProcesos: s.minimoProcesos, Procesos: []procesoFairCheckpoint{}}
+	for pid, proceso := range s.procesos {
+		guardado := procesoFairCheckpoint{
+			Pid:         pid,
+			Vruntime:    proceso.vruntime,
+			Hilos:       make(map[int]float64),
+			MinimoHilos: proceso.minimoHilos,
+			Bloqueado:   proceso.bloqueado,
+		}
+		for tid, vruntime := range proceso.hilos {
+			guardado.Hilos[tid] = vruntime
+		}
+		for tid := range proceso.bloqueados {
+			guardado.Bloqueados = append(guardado.Bloqueados, tid)
+		}
+		estado.Procesos = append(estado.Procesos, guardado)
+	}
+	return json.Marshal(estado)
+}
+
+func (s *schedulerFair) Importar(datos json.RawMessage) error {
+	var estado estadoFairCheckpoint
+	if err := json.Unmarshal(datos, &estado); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.minimoProcesos = estado.MinimoProcesos
+	for _, guardado := range estado.Procesos {
+		proceso := &vruntimeProceso{
+			vruntime:    guardado.Vruntime,
+			hilos:       make(map[int]float64),
+			minimoHilos: guardado.MinimoHilos,
+			bloqueados:  make(map[int]bool),
+			bloqueado:   guardado.Bloqueado,
+		}
+		for tid, vruntime := range guardado.Hilos {
+			proceso.hilos[tid] = vruntime
+		}
+		for _, tid := range guardado.Bloqueados {
+			proceso.bloqueados[tid] = true
+		}
+		s.procesos[guardado.Pid] = proceso
+	}
+	return nil
+}
+
+func (s *schedulerFair) Bloqueado(hilo TCB) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	proceso, ok := s.procesos[hilo.Pid]
+	if !ok {
+		return
+	}
+	proceso.bloqueados[hilo.Tid] = true
+	if len(proceso.bloqueados) == len(proceso.hilos) {
+		proceso.bloqueado = true
+	}
+}
+
+// ajustarDespertados lleva a los hilos que volvieron de un bloqueo, y a sus
+// procesos si estaban bloqueados enteros, al minimo actual menos medio
+// quantum. Sin esto vuelven con el tiempo virtual de antes de bloquearse y
+// acaparan la CPU hasta alcanzar a los demas. Requiere el mutex tomado.
+func (s *schedulerFair) ajustarDespertados(colaReady []TCB) {
+	bonificacion := float64(s.quantum) / 2
+
+	for _, hilo := range colaReady {
+		proceso, ok := s.procesos[hilo.Pid]
+		if !ok || !proceso.bloqueados[hilo.Tid] {
+			continue
+		}
+		delete(proceso.bloqueados, hilo.Tid)
+
+		if vruntime, ok := proceso.hilos[hilo.Tid]; ok {
+			proceso.hilos[hilo.Tid] = math.Max(vruntime, proceso.minimoHilos-bonificacion)
+		}
+		if proceso.bloqueado {
+			proceso.bloqueado = false
+			proceso.vruntime = math.Max(proceso.vruntime, s.minimoProcesos-bonificacion)
+		}
+	}
+}
+
+// actualizarMinimos avanza los minimos con los procesos e hilos que ya estaban
+// en READY, antes de que se sumen los nuevos. Requiere el mutex tomado.
+func (s *schedulerFair) actualizarMinimos(colaReady []TCB) {
+	minimoProcesos := math.Inf(1)
+	minimosHilos := make(map[int]float64)
+
+	for _, hilo := range colaReady {
+		proceso, ok := s.procesos[hilo.Pid]
+		if !ok {
+			continue
+		}
+		minimoProcesos = math.Min(minimoProcesos, proceso.vruntime)
+
+		vruntimeHilo, ok := proceso.hilos[hilo.Tid]
+		if !ok {
+			continue
+		}
+		if minimo, ok := minimosHilos[hilo.Pid]; !ok || vruntimeHilo < minimo {
+			minimosHilos[hilo.Pid] = vruntimeHilo
+		}
+	}
+
+	if !math.IsInf(minimoProcesos, 1) {
+		s.minimoProcesos = math.Max(s.minimoProcesos, minimoProcesos)
+	}
+	for pid, minimo := range minimosHilos {
+		proceso := s.procesos[pid]
+		proceso.minimoHilos = math.Max(proceso.minimoHilos, minimo)
+	}
+}
+
+// proceso devuelve el tiempo virtual del proceso, si es nuevo arranca en el minimo. Requiere el mutex tomado.
+func (s *schedulerFair) proceso(pid int) *vruntimeProceso {
+	proceso, ok := s.procesos[pid]
+	if !ok {
+		proceso = &vruntimeProceso{
+			vruntime:    s.minimoProcesos,
+			hilos:       make(map[int]float64),
+			minimoHilos: 0,
+			bloqueados:  make(map[int]bool),
+		}
+		s.procesos[pid] = proceso
+	}
+	return proceso
+}
+
+// vruntimeHilo devuelve el tiempo virtual del hilo, si es nuevo arranca en el minimo de su proceso. Requiere el mutex tomado.
+func (s *schedulerFair) vruntimeHilo(proceso *vruntimeProceso, tid int) float64 {
+	vruntime, ok := proceso.hilos[tid]
+	if !ok {
+		vruntime = proceso.minimoHilos
+		proceso.hilos[tid] = vruntime
+	}
+	return vruntime
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"log"
+	"sync"
+)
+
+/*---------------------- HERENCIA DE PRIORIDAD ----------------------*/
+
+// Con herencia de prioridad, el hilo que tiene asignado un mutex compite con
+// la mejor prioridad entre la suya y la de los hilos bloqueados en ese mutex.
+// La herencia es transitiva: si el dueño del mutex espera a su vez otro mutex,
+// el dueño de ese otro mutex tambien hereda la prioridad. Solo tiene efecto en
+// los algoritmos con prioridad efectiva (PRIORIDADES y CMN).
+
+// Prioridad heredada de los hilos que tienen una mejor que la propia
+var prioridadesHeredadas = make(map[claveHilo]int)
+var mutexPrioridadesHeredadas sync.Mutex
+
+func herenciaHabilitada() bool {
+	if ConfigKernel == nil || !ConfigKernel.HerenciaPrioridad || planificador == nil {
+		return false
+	}
+	_, ok := planificador.algoritmo.(schedulerConPrioridadEfectiva)
+	return ok
+}
+
+// recalcularHerencia se llama cada vez que cambian los dueños o las colas de los mutex
+func recalcularHerencia() {
+	if !herenciaHabilitada() {
+		return
+	}
+
+	foto := tomarFoto()
+
+	esperaA := make(map[claveHilo]claveHilo)
+	for _, proceso := range foto.procesos {
+		for _, mutex := range proceso.Mutex {
+			if mutex.HiloUsando < 0 {
+				continue
+			}
+			for _, bloqueado := range mutex.ColaBloqueados {
+				esperaA[claveHilo{bloqueado.Pid, bloqueado.Tid}] = claveHilo{proceso.Pid, mutex.HiloUsando}
+			}
+		}
+	}
+
+	propias := make(map[claveHilo]int)
+	efectivas := make(map[claveHilo]int)
+	for _, hilo := range foto.hilos {
+		clave := claveHilo{hilo.Pid, hilo.Tid}
+		propias[clave] = hilo.Prioridad
+		efectivas[clave] = hilo.Prioridad
+	}
+
+	// Se propaga la prioridad por las cadenas de mutex hasta que no cambia nada.
+	// Como las prioridades solo mejoran, termina aunque haya un deadlock.
+	for cambio := true; cambio; {
+		cambio = false
+		for bloqueado, dueño := range esperaA {
+			prioridadBloqueado, okBloqueado := efectivas[bloqueado]
+			prioridadDueño, okDueño := efectivas[dueño]
+			if okBloqueado && okDueño && prioridadBloqueado < prioridadDueño {
+				efectivas[dueño] = prioridadBloqueado
+				cambio = true
+			}
+		}
+	}
+
+	heredadas := make(map[claveHilo]int)
+	for clave, prioridad := range efectivas {
+		if prioridad < propias[clave] {
+			heredadas[clave] = prioridad
+		}
+	}
+
+	mutexPrioridadesHeredadas.Lock()
+	hayCambios := false
+	for clave, prioridad := range heredadas {
+		if anterior, ok := prioridadesHeredadas[clave]; !ok || anterior != prioridad {
+			log.Printf("## (<PID %d>:<TID %d>) Hereda prioridad %d - Prioridad propia: %d ##", clave.Pid, clave.Tid, prioridad, propias[clave])
+			hayCambios = true
+		}
+	}
+	for clave := range prioridadesHeredadas {
+		if _, ok := heredadas[clave]; !ok {
+			if propia, existe := propias[clave]; existe {
+				log.Printf("## (<PID %d>:<TID %d>) Se restaura la prioridad propia: %d ##", clave.Pid, clave.Tid, propia)
+			}
+			hayCambios = true
+		}
+	}
+	prioridadesHeredadas = heredadas
+	mutexPrioridadesHeredadas.Unlock()
+
+	if hayCambios {
+		planificador.notificar(Evento{Tipo: EventoHerencia})
+	}
+}
+
+// aplicarHerencia devuelve la mejor prioridad entre la calculada por el algoritmo y la heredada
+func aplicarHerencia(hilo TCB, prioridad int) int {
+	if heredada, ok := prioridadHeredada(hilo); ok && heredada < prioridad {
+		return heredada
+	}
+	return prioridad
+}
+
+func prioridadHeredada(hilo TCB) (int, bool) {
+	mutexPrioridadesHeredadas.Lock()
+	defer mutexPrioridadesHeredadas.Unlock()
+
+	prioridad, ok := prioridadesHeredadas[claveHilo{hilo.Pid, hilo.Tid}]
+	return prioridad, ok
+}
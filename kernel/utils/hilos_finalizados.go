@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"log"
+	"sync"
+)
+
+/*---------------------- CODIGOS DE SALIDA DE HILOS ----------------------*/
+
+// Cuando un hilo finaliza se guarda solo su codigo de salida (el hilo zombie)
+// hasta que finaliza el proceso, asi un THREAD_JOIN posterior lo puede leer.
+// THREAD_JOIN <tid> <registro> deja el codigo en el registro del hilo que
+// espera; si el hilo nunca existio deja CodigoJoinInvalido.
+
+const (
+	CodigoSalidaNormal    uint32 = 0          // THREAD_EXIT sin registro
+	CodigoSalidaCancelado uint32 = 0xFFFFFFFF // THREAD_CANCEL, PROCESS_EXIT, el operador o un deadlock
+	CodigoJoinInvalido    uint32 = 0xFFFFFFFE // join a un hilo que nunca existio o a si mismo
+)
+
+type FinHiloRequest struct {
+	Pid    int    `json:"pid"`
+	Tid    int    `json:"tid"`
+	Codigo uint32 `json:"codigo"`
+}
+
+var hilosFinalizados = make(map[claveHilo]uint32)
+var mutexHilosFinalizados sync.Mutex
+
+// Registro en el que cada hilo bloqueado por THREAD_JOIN espera el codigo de salida
+var registrosJoin = make(map[claveHilo]string)
+var mutexRegistrosJoin sync.Mutex
+
+func registrarFinHilo(hilo TCB, codigo uint32) {
+	mutexHilosFinalizados.Lock()
+	hilosFinalizados[claveHilo{hilo.Pid, hilo.Tid}] = codigo
+	mutexHilosFinalizados.Unlock()
+
+	mutexRegistrosJoin.Lock()
+	delete(registrosJoin, claveHilo{hilo.Pid, hilo.Tid})
+	mutexRegistrosJoin.Unlock()
+
+	log.Printf("## (<PID %d>:<TID %d>) Finaliza el hilo - Codigo de salida: %d ##", hilo.Pid, hilo.Tid, codigo)
+}
+
+func codigoSalida(pid int, tid int) (uint32, bool) {
+	mutexHilosFinalizados.Lock()
+	defer mutexHilosFinalizados.Unlock()
+
+	codigo, ok := hilosFinalizados[claveHilo{pid, tid}]
+	return codigo, ok
+}
+
+// olvidarHilosFinalizados descarta los zombies del proceso cuando este finaliza
+func olvidarHilosFinalizados(pid int) {
+	mutexHilosFinalizados.Lock()
+	for clave := range hilosFinalizados {
+		if clave.Pid == pid {
+			delete(hilosFinalizados, clave)
+		}
+	}
+	mutexHilosFinalizados.Unlock()
+}
+
+func esperarCodigoSalida(hilo TCB, registro string) {
+	if registro == "" {
+		return
+	}
+	mutexRegistrosJoin.Lock()
+	registrosJoin[claveHilo{hilo.Pid, hilo.Tid}] = registro
+	mutexRegistrosJoin.Unlock()
+}
+
+// entregarCodigoSalida escribe el codigo en el registro del hilo que hizo THREAD_JOIN, si pidio uno
+func entregarCodigoSalida(hilo TCB, codigo uint32) {
+	mutexRegistrosJoin.Lock()
+	registro, ok := registrosJoin[claveHilo{hilo.Pid, hilo.Tid}]
+	delete(registrosJoin, claveHilo{hilo.Pid, hilo.Tid})
+	mutexRegistrosJoin.Unlock()
+
+	if ok {
+		escribirRegistro(hilo, registro, codigo)
+	}
+}
+
+/*---------------------- ESPERAS DE THREAD_JOIN ----------------------*/
+
+// Los hilos que esperan a cada hilo se guardan aparte del TCB: las colas de
+// mutex, semaforos e IO tienen copias del TCB que no ven los joins posteriores.
+var esperasJoin = make(map[claveHilo][]int)
+var mutexEsperasJoin sync.Mutex
+
+// agregarEsperaJoin anota que tidQueEspera espera a tidEsperado. Si tidEsperado
+// ya finalizo no se anota y se devuelve su codigo de salida.
+func agregarEsperaJoin(pid int, tidEsperado int, tidQueEspera int) (uint32, bool) {
+	mutexEsperasJoin.Lock()
+	defer mutexEsperasJoin.Unlock()
+
+	if codigo, finalizado := codigoSalida(pid, tidEsperado); finalizado {
+		return codigo, true
+	}
+	clave := claveHilo{pid, tidEsperado}
+	esperasJoin[clave] = append(esperasJoin[clave], tidQueEspera)
+	return 0, false
+}
+
+// tomarEsperasJoin devuelve los hilos que esperaban al hilo que finaliza.
+// Se llama despues de registrarFinHilo, asi ningun join nuevo queda sin despertar.
+func tomarEsperasJoin(hilo TCB) []int {
+	mutexEsperasJoin.Lock()
+	defer mutexEsperasJoin.Unlock()
+
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	tids := esperasJoin[clave]
+	delete(esperasJoin, clave)
+	return tids
+}
+
+func hilosEsperandoJoin(hilo TCB) []int {
+	mutexEsperasJoin.Lock()
+	defer mutexEsperasJoin.Unlock()
+
+	return append([]int{}, esperasJoin[claveHilo{hilo.Pid, hilo.Tid}]...)
+}
+
+// quitarDeEsperasJoin saca al hilo de los joins en los que estaba esperando
+func quitarDeEsperasJoin(hilo TCB) {
+	mutexEsperasJoin.Lock()
+	defer mutexEsperasJoin.Unlock()
+
+	for clave, tids := range esperasJoin {
+		if clave.Pid != hilo.Pid {
+			continue
+		}
+		esperasJoin[clave] = removeTid(tids, hilo.Tid)
+	}
+}
@@ -0,0 +1,265 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+/*---------------------- CONSULTAS DEL ESTADO DEL KERNEL ----------------------*/
+
+// Endpoints de solo lectura para ver el estado del kernel sin revisar tp.log.
+// Las respuestas salen de una foto de las colas tomada con todos sus mutex a la
+// vez, asi un hilo o proceso nunca aparece en dos estados ni en ninguno.
+
+type ProcesoEstado struct {
+	Pid         int               `json:"pid"`
+	Padre       *int              `json:"padre,omitempty"`
+	Estado      string            `json:"estado"`
+	Size        int               `json:"size,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	Prioridad   int               `json:"prioridad,omitempty"`
+	Tids        []int             `json:"tids"`
+	Mutex       []MutexEstado     `json:"mutex"`
+	Semaforos   []SemaforoEstado  `json:"semaforos"`
+	Condiciones []CondicionEstado `json:"condiciones"`
+	Hilos       []HiloEstado      `json:"hilos,omitempty"`
+}
+
+type HiloEstado struct {
+	Pid               int    `json:"pid"`
+	Tid               int    `json:"tid"`
+	Prioridad         int    `json:"prioridad"`
+	PrioridadHeredada *int   `json:"prioridadHeredada,omitempty"` // solo si hereda una mejor por un mutex
+	Estado            string `json:"estado"`
+	HilosBloqueados   []int  `json:"hilosBloqueados"`
+}
+
+type MutexEstado struct {
+	Pid            int          `json:"pid"`
+	Nombre         string       `json:"nombre"`
+	Bloqueado      bool         `json:"bloqueado"`
+	HiloUsando     int          `json:"hiloUsando"`
+	ColaBloqueados []TCBRequest `json:"colaBloqueados"`
+}
+
+type SemaforoEstado struct {
+	Nombre         string       `json:"nombre"`
+	Valor          int          `json:"valor"`
+	ColaBloqueados []TCBRequest `json:"colaBloqueados"`
+}
+
+type CondicionEstado struct {
+	Nombre         string       `json:"nombre"`
+	ColaBloqueados []TCBRequest `json:"colaBloqueados"`
+}
+
+type fotoKernel struct {
+	procesos []ProcesoEstado
+	hilos    []HiloEstado
+}
+
+var estadosHilo = []string{"READY", "EXEC", "BLOCK", "EXIT"}
+
+// tomarFoto copia las colas de procesos e hilos. Los mutex se toman siempre en
+// el orden en que estan declarados.
+func tomarFoto() fotoKernel {
+	mutexProcesosSinIniciar.Lock()
+	mutexColaExitproceso.Lock()
+	mutexColaProcesosInicializados.Lock()
+	mutexColaReadyHilo.Lock()
+	mutexColaExecHilo.Lock()
+	mutexColaBlockHilo.Lock()
+	mutexColaExitHilo.Lock()
+
+	var foto fotoKernel
+
+	for _, proceso := range colaProcesosSinIniciar {
+		estado := procesoEstado(proceso.PCB, "NEW")
+		estado.Size = proceso.Size
+		estado.Path = proceso.Path
+		estado.Prioridad = proceso.Prioridad
+		foto.procesos = append(foto.procesos, estado)
+	}
+	for _, pcb := range colaProcesosInicializados {
+		foto.procesos = append(foto.procesos, procesoEstado(pcb, "INICIALIZADO"))
+	}
+	for _, pcb := range colaExitproceso {
+		foto.procesos = append(foto.procesos, procesoEstado(pcb, "EXIT"))
+	}
+
+	colasHilo := map[string][]TCB{
+		"READY": colaReadyHilo,
+		"EXEC":  colaExecHilo,
+		"BLOCK": colaBlockHilo,
+		"EXIT":  colaExitHilo,
+	}
+	for _, estado := range estadosHilo {
+		for _, tcb := range colasHilo[estado] {
+			foto.hilos = append(foto.hilos, HiloEstado{
+				Pid:             tcb.Pid,
+				Tid:             tcb.Tid,
+				Prioridad:       tcb.Prioridad,
+				Estado:          estado,
+				HilosBloqueados: hilosEsperandoJoin(tcb),
+			})
+		}
+	}
+
+	mutexColaExitHilo.Unlock()
+	mutexColaBlockHilo.Unlock()
+	mutexColaExecHilo.Unlock()
+	mutexColaReadyHilo.Unlock()
+	mutexColaProcesosInicializados.Unlock()
+	mutexColaExitproceso.Unlock()
+	mutexProcesosSinIniciar.Unlock()
+
+	for i := range foto.procesos {
+		if padre, ok := padreDe(foto.procesos[i].Pid); ok {
+			foto.procesos[i].Padre = &padre
+		}
+	}
+	for i := range foto.hilos {
+		hilo := TCB{Pid: foto.hilos[i].Pid, Tid: foto.hilos[i].Tid}
+		if prioridad, ok := prioridadHeredada(hilo); ok {
+			foto.hilos[i].PrioridadHeredada = &prioridad
+		}
+	}
+
+	return foto
+}
+
+// procesoEstado copia el PCB para que la respuesta no comparta slices con las colas
+func procesoEstado(pcb PCB, estado string) ProcesoEstado {
+	proceso := ProcesoEstado{
+		Pid:         pcb.Pid,
+		Estado:      estado,
+		Tids:        append([]int{}, pcb.Tid...),
+		Mutex:       []MutexEstado{},
+		Semaforos:   []SemaforoEstado{},
+		Condiciones: []CondicionEstado{},
+	}
+	for _, mutex := range pcb.Mutex {
+		bloqueados := []TCBRequest{}
+		for _, tcb := range mutex.colaBloqueados {
+			bloqueados = append(bloqueados, TCBRequest{Pid: tcb.Pid, Tid: tcb.Tid})
+		}
+		proceso.Mutex = append(proceso.Mutex, MutexEstado{
+			Pid:            pcb.Pid,
+			Nombre:         mutex.Nombre,
+			Bloqueado:      mutex.Bloqueado,
+			HiloUsando:     mutex.HiloUsando,
+			ColaBloqueados: bloqueados,
+		})
+	}
+	for _, semaforo := range pcb.Semaforos {
+		bloqueados := []TCBRequest{}
+		for _, tcb := range semaforo.colaBloqueados {
+			bloqueados = append(bloqueados, TCBRequest{Pid: tcb.Pid, Tid: tcb.Tid})
+		}
+		proceso.Semaforos = append(proceso.Semaforos, SemaforoEstado{
+			Nombre:         semaforo.Nombre,
+			Valor:          semaforo.Valor,
+			ColaBloqueados: bloqueados,
+		})
+	}
+	for _, condicion := range pcb.Condiciones {
+		bloqueados := []TCBRequest{}
+		for _, espera := range condicion.colaBloqueados {
+			bloqueados = append(bloqueados, TCBRequest{Pid: espera.hilo.Pid, Tid: espera.hilo.Tid})
+		}
+		proceso.Condiciones = append(proceso.Condiciones, CondicionEstado{
+			Nombre:         condicion.Nombre,
+			ColaBloqueados: bloqueados,
+		})
+	}
+	return proceso
+}
+
+func responderJSON(w http.ResponseWriter, respuesta any) {
+	respuestaJson, err := json.Marshal(respuesta)
+	if err != nil {
+		http.Error(w, "Error al codificar los datos como JSON", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respuestaJson)
+}
+
+// GET /procesos
+func ListarProcesos(w http.ResponseWriter, r *http.Request) {
+	foto := tomarFoto()
+
+	procesos := []ProcesoEstado{}
+	if foto.procesos != nil {
+		procesos = foto.procesos
+	}
+	responderJSON(w, procesos)
+}
+
+// GET /procesos/{pid}, incluye los hilos del proceso
+func ConsultarProceso(w http.ResponseWriter, r *http.Request) {
+	pid, err := strconv.Atoi(r.PathValue("pid"))
+	if err != nil {
+		http.Error(w, "pid invalido", http.StatusBadRequest)
+		return
+	}
+
+	foto := tomarFoto()
+
+	for _, proceso := range foto.procesos {
+		if proceso.Pid != pid {
+			continue
+		}
+		proceso.Hilos = []HiloEstado{}
+		for _, hilo := range foto.hilos {
+			if hilo.Pid == pid {
+				proceso.Hilos = append(proceso.Hilos, hilo)
+			}
+		}
+		responderJSON(w, proceso)
+		return
+	}
+	http.Error(w, fmt.Sprintf("no existe el proceso %d", pid), http.StatusNotFound)
+}
+
+// GET /hilos?estado=READY, sin estado devuelve todos los hilos
+func ListarHilos(w http.ResponseWriter, r *http.Request) {
+	estado := r.URL.Query().Get("estado")
+	if estado != "" && !estadoHiloValido(estado) {
+		http.Error(w, fmt.Sprintf("estado %s no valido", estado), http.StatusBadRequest)
+		return
+	}
+
+	foto := tomarFoto()
+
+	hilos := []HiloEstado{}
+	for _, hilo := range foto.hilos {
+		if estado == "" || hilo.Estado == estado {
+			hilos = append(hilos, hilo)
+		}
+	}
+	responderJSON(w, hilos)
+}
+
+// GET /mutex, los mutex de todos los procesos
+func ListarMutex(w http.ResponseWriter, r *http.Request) {
+	foto := tomarFoto()
+
+	mutex := []MutexEstado{}
+	for _, proceso := range foto.procesos {
+		mutex = append(mutex, proceso.Mutex...)
+	}
+	responderJSON(w, mutex)
+}
+
+func estadoHiloValido(estado string) bool {
+	for _, e := range estadosHilo {
+		if e == estado {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+)
+
+/*---------------------- MEMORIA COMPARTIDA ----------------------*/
+
+// SHM_CREATE <nombre> <tamaño> <registro> crea en memoria una region compartida
+// con nombre, dejando 0 en el registro, y SHM_ATTACH <nombre> <registro> la
+// adjunta al proceso, dejando en el registro la direccion logica donde la ve.
+// La MMU de la CPU traduce ese rango reservado a la region. Si la syscall falla
+// el registro queda en RegionCompartidaInvalida y el hilo sigue.
+
+const RegionCompartidaInvalida uint32 = 0xFFFFFFFF
+
+type MemoriaCompartidaRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Nombre   string `json:"nombre"`
+	Tamanio  int    `json:"tamanio"` // solo SHM_CREATE
+	Registro string `json:"registro"`
+}
+
+type RegionCompartidaRequest struct {
+	Pid     int    `json:"pid"`
+	Nombre  string `json:"nombre"`
+	Tamanio int    `json:"tamanio,omitempty"`
+}
+
+type RegionAdjunta struct {
+	Nombre  string `json:"nombre"`
+	Base    uint32 `json:"base"`
+	Tamanio uint32 `json:"tamanio"`
+}
+
+func CrearMemoriaCompartida(w http.ResponseWriter, r *http.Request) {
+	var solicitud MemoriaCompartidaRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&solicitud)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := solicitud.Pid
+	tid := solicitud.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SHM_CREATE> ##", pid, tid)
+
+	resultado := uint32(0)
+	regionRequest := RegionCompartidaRequest{Pid: pid, Nombre: solicitud.Nombre, Tamanio: solicitud.Tamanio}
+	respuesta, err := pedirRegionCompartida(regionRequest, "crearRegionCompartida")
+	if err != nil {
+		slog.Warn("No se pudo crear la region compartida", slog.String("nombre", solicitud.Nombre), slog.Any("error", err))
+		resultado = RegionCompartidaInvalida
+	} else {
+		respuesta.Body.Close()
+	}
+
+	tcbActual := getTCB(pid, tid)
+	escribirRegistro(tcbActual, solicitud.Registro, resultado)
+	enviarTCBCpu(tcbActual)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func AdjuntarMemoriaCompartida(w http.ResponseWriter, r *http.Request) {
+	var solicitud MemoriaCompartidaRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&solicitud)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := solicitud.Pid
+	tid := solicitud.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SHM_ATTACH> ##", pid, tid)
+
+	base := RegionCompartidaInvalida
+	regionRequest := RegionCompartidaRequest{Pid: pid, Nombre: solicitud.Nombre}
+	respuesta, err := pedirRegionCompartida(regionRequest, "adjuntarRegionCompartida")
+	if err != nil {
+		slog.Warn("No se pudo adjuntar la region compartida", slog.String("nombre", solicitud.Nombre), slog.Any("error", err))
+	} else {
+		var adjunta RegionAdjunta
+		if err := json.NewDecoder(respuesta.Body).Decode(&adjunta); err != nil {
+			slog.Error("Error decodificando la region adjuntada", slog.Any("error", err))
+		} else {
+			base = adjunta.Base
+		}
+		respuesta.Body.Close()
+	}
+
+	tcbActual := getTCB(pid, tid)
+	escribirRegistro(tcbActual, solicitud.Registro, base)
+	enviarTCBCpu(tcbActual)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func pedirRegionCompartida(regionRequest RegionCompartidaRequest, endpoint string) (*http.Response, error) {
+	puerto := ConfigKernel.PuertoMemoria
+	ip := ConfigKernel.IpMemoria
+
+	body, err := json.Marshal(&regionRequest)
+	if err != nil {
+		slog.Error("error codificando" + err.Error())
+		return nil, err
+	}
+
+	url := fmt.Sprintf("http://%s:%d/%s", ip, puerto, endpoint)
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("memoria respondio %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+/*---------------------- COLAS DE MENSAJES ----------------------*/
+
+// Colas de mensajes con nombre, compartidas entre procesos. Cada mensaje es el
+// valor de un registro. La cola se crea con el primer MQ_SEND o MQ_RECEIVE que
+// la nombra y se destruye cuando finaliza el ultimo proceso que la uso.
+// MQ_SEND bloquea con la cola llena y MQ_RECEIVE con la cola vacia.
+
+const capacidadColaMensajesPorDefecto = 10
+
+type ColaMensajes struct {
+	Nombre    string
+	Capacidad int
+
+	mensajes   []uint32
+	emisores   []envioBloqueado
+	receptores []recepcionBloqueada
+	procesos   map[int]bool // procesos que usaron la cola
+}
+
+type envioBloqueado struct {
+	hilo  TCB
+	valor uint32
+}
+
+type recepcionBloqueada struct {
+	hilo     TCB
+	registro string
+}
+
+type MensajeRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Cola     string `json:"cola"`
+	Valor    uint32 `json:"valor"`    // solo MQ_SEND
+	Registro string `json:"registro"` // solo MQ_RECEIVE
+}
+
+var colasMensajes = make(map[string]*ColaMensajes)
+var mutexColasMensajes sync.Mutex
+
+func EnviarMensaje(w http.ResponseWriter, r *http.Request) {
+	var mensaje MensajeRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&mensaje)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := mensaje.Pid
+	tid := mensaje.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <MQ_SEND> ##", pid, tid)
+
+	hiloSolicitante := getTCB(pid, tid)
+
+	mutexColasMensajes.Lock()
+	cola := usarColaMensajes(mensaje.Cola, pid)
+
+	// Si hay un receptor esperando el mensaje se le entrega directamente
+	if len(cola.receptores) > 0 {
+		receptor := cola.receptores[0]
+		cola.receptores = cola.receptores[1:]
+		mutexColasMensajes.Unlock()
+
+		escribirRegistro(receptor.hilo, receptor.registro, mensaje.Valor)
+		despertarHiloBloqueado(receptor.hilo)
+		enviarTCBCpu(hiloSolicitante)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if len(cola.mensajes) < cola.Capacidad {
+		cola.mensajes = append(cola.mensajes, mensaje.Valor)
+		mutexColasMensajes.Unlock()
+
+		enviarTCBCpu(hiloSolicitante)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// El hilo pasa a BLOCK antes de soltar la cola, asi quien lo despierte
+	// siempre lo encuentra bloqueado
+	quitarExec(hiloSolicitante)
+	encolarBlock(hiloSolicitante, "MQ_SEND")
+	cola.emisores = append(cola.emisores, envioBloqueado{hilo: hiloSolicitante, valor: mensaje.Valor})
+	mutexColasMensajes.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func RecibirMensaje(w http.ResponseWriter, r *http.Request) {
+	var mensaje MensajeRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&mensaje)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := mensaje.Pid
+	tid := mensaje.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <MQ_RECEIVE> ##", pid, tid)
+
+	hiloSolicitante := getTCB(pid, tid)
+
+	mutexColasMensajes.Lock()
+	cola := usarColaMensajes(mensaje.Cola, pid)
+
+	if len(cola.mensajes) == 0 {
+		quitarExec(hiloSolicitante)
+		encolarBlock(hiloSolicitante, "MQ_RECEIVE")
+		cola.receptores = append(cola.receptores, recepcionBloqueada{hilo: hiloSolicitante, registro: mensaje.Registro})
+		mutexColasMensajes.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	valor := cola.mensajes[0]
+	cola.mensajes = cola.mensajes[1:]
+
+	// Se libero un lugar, el primer emisor bloqueado deja su mensaje y sigue
+	hayEmisor := len(cola.emisores) > 0
+	var emisor envioBloqueado
+	if hayEmisor {
+		emisor = cola.emisores[0]
+		cola.emisores = cola.emisores[1:]
+		cola.mensajes = append(cola.mensajes, emisor.valor)
+	}
+	mutexColasMensajes.Unlock()
+
+	if hayEmisor {
+		despertarHiloBloqueado(emisor.hilo)
+	}
+
+	escribirRegistro(hiloSolicitante, mensaje.Registro, valor)
+	enviarTCBCpu(hiloSolicitante)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// usarColaMensajes devuelve la cola, creandola si no existe, y la anota como
+// usada por el proceso. Requiere mutexColasMensajes tomado.
+func usarColaMensajes(nombre string, pid int) *ColaMensajes {
+	cola, existe := colasMensajes[nombre]
+	if !existe {
+		capacidad := ConfigKernel.CapacidadColaMensajes
+		if capacidad <= 0 {
+			capacidad = capacidadColaMensajesPorDefecto
+		}
+		cola = &ColaMensajes{Nombre: nombre, Capacidad: capacidad, procesos: make(map[int]bool)}
+		colasMensajes[nombre] = cola
+		log.Printf("## Se crea la cola de mensajes %s - Capacidad: %d ##", nombre, capacidad)
+	}
+	cola.procesos[pid] = true
+	return cola
+}
+
+// despertarHiloBloqueado pasa el hilo a READY. Los hilos se bloquean antes de
+// anotarse como esperando, asi que si no esta en BLOCK es porque finalizo.
+func despertarHiloBloqueado(hilo TCB) {
+	mutexColaBlockHilo.Lock()
+	hiloBloqueado, err := buscarPorPidYTid(colaBlockHilo, hilo.Pid, hilo.Tid)
+	mutexColaBlockHilo.Unlock()
+	if err != nil {
+		slog.Warn("El hilo finalizo antes de ser despertado", slog.Int("pid", hilo.Pid), slog.Int("tid", hilo.Tid))
+		return
+	}
+	quitarBlock(hiloBloqueado)
+	encolarReady(hiloBloqueado)
+}
+
+// quitarDeColasDeMensajes saca al hilo de los emisores y receptores bloqueados de todas las colas
+func quitarDeColasDeMensajes(hilo TCB) {
+	mutexColasMensajes.Lock()
+	defer mutexColasMensajes.Unlock()
+
+	for _, cola := range colasMensajes {
+		for i, emisor := range cola.emisores {
+			if emisor.hilo.Pid == hilo.Pid && emisor.hilo.Tid == hilo.Tid {
+				cola.emisores = append(cola.emisores[:i], cola.emisores[i+1:]...)
+				break
+			}
+		}
+		for i, receptor := range cola.receptores {
+			if receptor.hilo.Pid == hilo.Pid && receptor.hilo.Tid == hilo.Tid {
+				cola.receptores = append(cola.receptores[:i], cola.receptores[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// liberarColasDeMensajes destruye las colas que ya no usa ningun proceso
+func liberarColasDeMensajes(pid int) {
+	mutexColasMensajes.Lock()
+	defer mutexColasMensajes.Unlock()
+
+	for nombre, cola := range colasMensajes {
+		delete(cola.procesos, pid)
+		if len(cola.procesos) == 0 {
+			delete(colasMensajes, nombre)
+			log.Printf("## Se destruye la cola de mensajes %s - Mensajes descartados: %d ##", nombre, len(cola.mensajes))
+		}
+	}
+}
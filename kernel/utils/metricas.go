@@ -0,0 +1,372 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*---------------------- METRICAS DE PLANIFICACION ----------------------*/
+
+// Por cada hilo se acumula el tiempo en cada estado, los despachos y los
+// desalojos por motivo ("Quantum", "Prioridades", ...). El turnaround va desde
+// que el hilo entra por primera vez a READY hasta que finaliza y la espera es
+// el tiempo total en READY. GET /metrics las expone en formato de texto de
+// Prometheus por hilo, por proceso y por algoritmo, y cuando el sistema se
+// vacia se imprime un resumen.
+
+const (
+	EstadoReady string = "READY"
+	EstadoExec  string = "EXEC"
+	EstadoBlock string = "BLOCK"
+	EstadoExit  string = "EXIT"
+)
+
+var estadosConTiempo = []string{EstadoReady, EstadoExec, EstadoBlock}
+
+type metricasHilo struct {
+	pid, tid  int
+	estado    string
+	desde     time.Time
+	creado    time.Time
+	fin       time.Time // cero mientras el hilo no finalizo
+	tiempos   map[string]time.Duration
+	despachos int
+	desalojos map[string]int
+}
+
+type metricasProceso struct {
+	creado time.Time
+	fin    time.Time
+}
+
+var metricasHilos = make(map[claveHilo]*metricasHilo)
+var metricasProcesos = make(map[int]*metricasProceso)
+var mutexMetricas sync.Mutex
+
+// cambiarEstadoMetricas se llama cada vez que el hilo entra a una cola
+func cambiarEstadoMetricas(hilo TCB, estado string) {
+	ahora := time.Now()
+
+	mutexMetricas.Lock()
+	defer mutexMetricas.Unlock()
+
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	metricas, ok := metricasHilos[clave]
+	if !ok {
+		metricas = &metricasHilo{
+			pid:       hilo.Pid,
+			tid:       hilo.Tid,
+			creado:    ahora,
+			tiempos:   make(map[string]time.Duration),
+			desalojos: make(map[string]int),
+		}
+		metricasHilos[clave] = metricas
+	} else if !metricas.fin.IsZero() {
+		return
+	} else {
+		metricas.tiempos[metricas.estado] += ahora.Sub(metricas.desde)
+	}
+
+	metricas.estado = estado
+	metricas.desde = ahora
+	switch estado {
+	case EstadoExec:
+		metricas.despachos++
+	case EstadoExit:
+		metricas.fin = ahora
+	}
+}
+
+func registrarDesalojo(hilo TCB, motivo string) {
+	mutexMetricas.Lock()
+	defer mutexMetricas.Unlock()
+
+	if metricas, ok := metricasHilos[claveHilo{hilo.Pid, hilo.Tid}]; ok {
+		metricas.desalojos[motivo]++
+	}
+}
+
+func registrarInicioProceso(pid int) {
+	mutexMetricas.Lock()
+	metricasProcesos[pid] = &metricasProceso{creado: time.Now()}
+	mutexMetricas.Unlock()
+}
+
+func registrarFinProceso(pid int) {
+	mutexMetricas.Lock()
+	if metricas, ok := metricasProcesos[pid]; ok && metricas.fin.IsZero() {
+		metricas.fin = time.Now()
+	}
+	mutexMetricas.Unlock()
+}
+
+/*---------- FOTO DE LAS METRICAS ----------*/
+
+type metricasAcumuladas struct {
+	tiempos     map[string]time.Duration
+	despachos   int
+	desalojos   map[string]int
+	finalizados int
+	turnaround  time.Duration // suma de los hilos finalizados
+	espera      time.Duration // suma de los hilos finalizados
+}
+
+type fotoMetricas struct {
+	algoritmo string
+	hilos     []metricasHilo
+	procesos  map[int]metricasProceso
+}
+
+// tomarFotoMetricas copia las metricas sumando el tiempo que lleva cada hilo en su estado actual
+func tomarFotoMetricas() fotoMetricas {
+	ahora := time.Now()
+	foto := fotoMetricas{algoritmo: nombreAlgoritmo(), procesos: make(map[int]metricasProceso)}
+
+	mutexMetricas.Lock()
+	for _, metricas := range metricasHilos {
+		copia := *metricas
+		copia.tiempos = make(map[string]time.Duration)
+		for estado, tiempo := range metricas.tiempos {
+			copia.tiempos[estado] = tiempo
+		}
+		if copia.fin.IsZero() {
+			copia.tiempos[copia.estado] += ahora.Sub(copia.desde)
+		}
+		copia.desalojos = make(map[string]int)
+		for motivo, cantidad := range metricas.desalojos {
+			copia.desalojos[motivo] = cantidad
+		}
+		foto.hilos = append(foto.hilos, copia)
+	}
+	for pid, metricas := range metricasProcesos {
+		foto.procesos[pid] = *metricas
+	}
+	mutexMetricas.Unlock()
+
+	sort.Slice(foto.hilos, func(i, j int) bool {
+		if foto.hilos[i].pid != foto.hilos[j].pid {
+			return foto.hilos[i].pid < foto.hilos[j].pid
+		}
+		return foto.hilos[i].tid < foto.hilos[j].tid
+	})
+	return foto
+}
+
+func nombreAlgoritmo() string {
+	if planificador == nil {
+		return ""
+	}
+	return planificador.algoritmo.Nombre()
+}
+
+func (m *metricasAcumuladas) sumar(hilo metricasHilo) {
+	if m.tiempos == nil {
+		m.tiempos = make(map[string]time.Duration)
+		m.desalojos = make(map[string]int)
+	}
+	for estado, tiempo := range hilo.tiempos {
+		m.tiempos[estado] += tiempo
+	}
+	m.despachos += hilo.despachos
+	for motivo, cantidad := range hilo.desalojos {
+		m.desalojos[motivo] += cantidad
+	}
+	if !hilo.fin.IsZero() {
+		m.finalizados++
+		m.turnaround += hilo.fin.Sub(hilo.creado)
+		m.espera += hilo.tiempos[EstadoReady]
+	}
+}
+
+func (m metricasAcumuladas) promedio(total time.Duration) time.Duration {
+	if m.finalizados == 0 {
+		return 0
+	}
+	return total / time.Duration(m.finalizados)
+}
+
+func (f fotoMetricas) porProceso() (map[int]*metricasAcumuladas, []int) {
+	procesos := make(map[int]*metricasAcumuladas)
+	var pids []int
+	for _, hilo := range f.hilos {
+		if _, ok := procesos[hilo.pid]; !ok {
+			procesos[hilo.pid] = &metricasAcumuladas{}
+			pids = append(pids, hilo.pid)
+		}
+		procesos[hilo.pid].sumar(hilo)
+	}
+	return procesos, pids
+}
+
+func (f fotoMetricas) total() metricasAcumuladas {
+	var total metricasAcumuladas
+	for _, hilo := range f.hilos {
+		total.sumar(hilo)
+	}
+	return total
+}
+
+/*---------- EXPOSICION ----------*/
+
+// GET /metrics
+func Metricas(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(tomarFotoMetricas().prometheus()))
+}
+
+type exposicion struct {
+	texto strings.Builder
+}
+
+func (e *exposicion) metrica(nombre string, tipo string, ayuda string) {
+	fmt.Fprintf(&e.texto, "# HELP %s %s\n# TYPE %s %s\n", nombre, ayuda, nombre, tipo)
+}
+
+func (e *exposicion) valor(nombre string, valor float64, etiquetas ...string) {
+	var pares []string
+	for i := 0; i+1 < len(etiquetas); i += 2 {
+		pares = append(pares, fmt.Sprintf("%s=%q", etiquetas[i], etiquetas[i+1]))
+	}
+	fmt.Fprintf(&e.texto, "%s{%s} %g\n", nombre, strings.Join(pares, ","), valor)
+}
+
+func motivosOrdenados(desalojos map[string]int) []string {
+	motivos := make([]string, 0, len(desalojos))
+	for motivo := range desalojos {
+		motivos = append(motivos, motivo)
+	}
+	sort.Strings(motivos)
+	return motivos
+}
+
+func (f fotoMetricas) prometheus() string {
+	var e exposicion
+	alg := f.algoritmo
+
+	e.metrica("kernel_hilo_tiempo_estado_segundos_total", "counter", "Tiempo acumulado del hilo en cada estado")
+	for _, hilo := range f.hilos {
+		for _, estado := range estadosConTiempo {
+			e.valor("kernel_hilo_tiempo_estado_segundos_total", hilo.tiempos[estado].Seconds(), "algoritmo", alg, "pid", fmt.Sprint(hilo.pid), "tid", fmt.Sprint(hilo.tid), "estado", estado)
+		}
+	}
+	e.metrica("kernel_hilo_despachos_total", "counter", "Veces que el hilo fue despachado a una CPU")
+	for _, hilo := range f.hilos {
+		e.valor("kernel_hilo_despachos_total", float64(hilo.despachos), "algoritmo", alg, "pid", fmt.Sprint(hilo.pid), "tid", fmt.Sprint(hilo.tid))
+	}
+	e.metrica("kernel_hilo_desalojos_total", "counter", "Desalojos del hilo por motivo")
+	for _, hilo := range f.hilos {
+		for _, motivo := range motivosOrdenados(hilo.desalojos) {
+			e.valor("kernel_hilo_desalojos_total", float64(hilo.desalojos[motivo]), "algoritmo", alg, "pid", fmt.Sprint(hilo.pid), "tid", fmt.Sprint(hilo.tid), "motivo", motivo)
+		}
+	}
+	e.metrica("kernel_hilo_turnaround_segundos", "gauge", "Tiempo desde que el hilo entro a READY hasta que finalizo")
+	for _, hilo := range f.hilos {
+		if !hilo.fin.IsZero() {
+			e.valor("kernel_hilo_turnaround_segundos", hilo.fin.Sub(hilo.creado).Seconds(), "algoritmo", alg, "pid", fmt.Sprint(hilo.pid), "tid", fmt.Sprint(hilo.tid))
+		}
+	}
+	e.metrica("kernel_hilo_espera_segundos", "gauge", "Tiempo total del hilo en READY")
+	for _, hilo := range f.hilos {
+		e.valor("kernel_hilo_espera_segundos", hilo.tiempos[EstadoReady].Seconds(), "algoritmo", alg, "pid", fmt.Sprint(hilo.pid), "tid", fmt.Sprint(hilo.tid))
+	}
+
+	procesos, pids := f.porProceso()
+	e.metrica("kernel_proceso_tiempo_estado_segundos_total", "counter", "Tiempo acumulado de los hilos del proceso en cada estado")
+	for _, pid := range pids {
+		for _, estado := range estadosConTiempo {
+			e.valor("kernel_proceso_tiempo_estado_segundos_total", procesos[pid].tiempos[estado].Seconds(), "algoritmo", alg, "pid", fmt.Sprint(pid), "estado", estado)
+		}
+	}
+	e.metrica("kernel_proceso_despachos_total", "counter", "Despachos de los hilos del proceso")
+	for _, pid := range pids {
+		e.valor("kernel_proceso_despachos_total", float64(procesos[pid].despachos), "algoritmo", alg, "pid", fmt.Sprint(pid))
+	}
+	e.metrica("kernel_proceso_desalojos_total", "counter", "Desalojos de los hilos del proceso por motivo")
+	for _, pid := range pids {
+		for _, motivo := range motivosOrdenados(procesos[pid].desalojos) {
+			e.valor("kernel_proceso_desalojos_total", float64(procesos[pid].desalojos[motivo]), "algoritmo", alg, "pid", fmt.Sprint(pid), "motivo", motivo)
+		}
+	}
+	e.metrica("kernel_proceso_turnaround_segundos", "gauge", "Tiempo desde que se creo el proceso hasta que finalizo")
+	for _, pid := range pids {
+		if proceso, ok := f.procesos[pid]; ok && !proceso.fin.IsZero() {
+			e.valor("kernel_proceso_turnaround_segundos", proceso.fin.Sub(proceso.creado).Seconds(), "algoritmo", alg, "pid", fmt.Sprint(pid))
+		}
+	}
+	e.metrica("kernel_proceso_espera_promedio_segundos", "gauge", "Tiempo promedio en READY de los hilos finalizados del proceso")
+	for _, pid := range pids {
+		e.valor("kernel_proceso_espera_promedio_segundos", procesos[pid].promedio(procesos[pid].espera).Seconds(), "algoritmo", alg, "pid", fmt.Sprint(pid))
+	}
+
+	total := f.total()
+	e.metrica("kernel_algoritmo_tiempo_estado_segundos_total", "counter", "Tiempo acumulado de todos los hilos en cada estado")
+	for _, estado := range estadosConTiempo {
+		e.valor("kernel_algoritmo_tiempo_estado_segundos_total", total.tiempos[estado].Seconds(), "algoritmo", alg, "estado", estado)
+	}
+	e.metrica("kernel_algoritmo_despachos_total", "counter", "Despachos de todos los hilos")
+	e.valor("kernel_algoritmo_despachos_total", float64(total.despachos), "algoritmo", alg)
+	e.metrica("kernel_algoritmo_desalojos_total", "counter", "Desalojos de todos los hilos por motivo")
+	for _, motivo := range motivosOrdenados(total.desalojos) {
+		e.valor("kernel_algoritmo_desalojos_total", float64(total.desalojos[motivo]), "algoritmo", alg, "motivo", motivo)
+	}
+	e.metrica("kernel_algoritmo_hilos_finalizados_total", "counter", "Hilos finalizados")
+	e.valor("kernel_algoritmo_hilos_finalizados_total", float64(total.finalizados), "algoritmo", alg)
+	e.metrica("kernel_algoritmo_turnaround_promedio_segundos", "gauge", "Turnaround promedio de los hilos finalizados")
+	e.valor("kernel_algoritmo_turnaround_promedio_segundos", total.promedio(total.turnaround).Seconds(), "algoritmo", alg)
+	e.metrica("kernel_algoritmo_espera_promedio_segundos", "gauge", "Tiempo promedio en READY de los hilos finalizados")
+	e.valor("kernel_algoritmo_espera_promedio_segundos", total.promedio(total.espera).Seconds(), "algoritmo", alg)
+
+	return e.texto.String()
+}
+
+/*---------- RESUMEN ----------*/
+
+// imprimirResumenSiVacio imprime el resumen de metricas cuando no queda ningun proceso por ejecutar
+func imprimirResumenSiVacio() {
+	mutexProcesosSinIniciar.Lock()
+	pendientes := len(colaProcesosSinIniciar)
+	mutexProcesosSinIniciar.Unlock()
+	mutexColaProcesosInicializados.Lock()
+	pendientes += len(colaProcesosInicializados)
+	mutexColaProcesosInicializados.Unlock()
+
+	if pendientes == 0 {
+		imprimirResumenMetricas()
+	}
+}
+
+func imprimirResumenMetricas() {
+	foto := tomarFotoMetricas()
+	total := foto.total()
+
+	log.Printf("## Resumen de metricas - Algoritmo: %s ##", foto.algoritmo)
+	procesos, pids := foto.porProceso()
+	for _, pid := range pids {
+		proceso := procesos[pid]
+		turnaround := time.Duration(0)
+		if metricas, ok := foto.procesos[pid]; ok && !metricas.fin.IsZero() {
+			turnaround = metricas.fin.Sub(metricas.creado)
+		}
+		log.Printf("## (<PID %d>) Turnaround: %v - READY: %v - EXEC: %v - BLOCK: %v - Despachos: %d - Desalojos: %s ##",
+			pid, turnaround, proceso.tiempos[EstadoReady], proceso.tiempos[EstadoExec], proceso.tiempos[EstadoBlock], proceso.despachos, formatearDesalojos(proceso.desalojos))
+	}
+	log.Printf("## Total - Hilos finalizados: %d - Turnaround promedio: %v - Espera promedio: %v - Despachos: %d - Desalojos: %s ##",
+		total.finalizados, total.promedio(total.turnaround), total.promedio(total.espera), total.despachos, formatearDesalojos(total.desalojos))
+}
+
+func formatearDesalojos(desalojos map[string]int) string {
+	if len(desalojos) == 0 {
+		return "ninguno"
+	}
+	var partes []string
+	for _, motivo := range motivosOrdenados(desalojos) {
+		partes = append(partes, fmt.Sprintf("%s=%d", motivo, desalojos[motivo]))
+	}
+	return strings.Join(partes, ", ")
+}
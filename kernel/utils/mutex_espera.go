@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*---------------------- MUTEX_TRYLOCK Y MUTEX_TIMEDLOCK ----------------------*/
+
+// Ambas syscalls dejan 1 en el registro indicado si el hilo consiguio el mutex
+// y 0 si no. MUTEX_TRYLOCK nunca bloquea; MUTEX_TIMEDLOCK bloquea al hilo en la
+// cola del mutex como MUTEX_LOCK pero, si se vence el tiempo antes de que se lo
+// asignen, lo saca de la cola y lo pasa a READY.
+
+type esperaMutex struct {
+	id       int
+	mutex    string
+	registro string
+	timer    *time.Timer
+}
+
+// Hilos que esperan un mutex con tiempo limite. El mutex tambien se toma al
+// sacar un hilo de la cola de un mutex, para que el vencimiento y la
+// asignacion del mutex no se pisen.
+var esperasMutex = make(map[claveHilo]*esperaMutex)
+var mutexEsperasMutex sync.Mutex
+var contadorEsperasMutex int
+
+func IntentarBloquearMutex(w http.ResponseWriter, r *http.Request) {
+	var mutex MutexRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&mutex)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := mutex.Pid
+	tid := mutex.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <MUTEX_TRYLOCK> ##", pid, tid)
+
+	proceso, _ := getPCB(pid)
+	hiloSolicitante := getTCB(pid, tid)
+
+	var resultado uint32
+	if asignarMutexLibre(proceso, hiloSolicitante, mutex.Mutex) {
+		resultado = 1
+	}
+	escribirRegistro(hiloSolicitante, mutex.Registro, resultado)
+
+	enviarTCBCpu(hiloSolicitante)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func BloquearMutexConTiempo(w http.ResponseWriter, r *http.Request) {
+	var mutex MutexRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&mutex)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := mutex.Pid
+	tid := mutex.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <MUTEX_TIMEDLOCK> ##", pid, tid)
+
+	proceso, _ := getPCB(pid)
+	hiloSolicitante := getTCB(pid, tid)
+
+	i, existe := buscarMutex(proceso, mutex.Mutex)
+	if !existe || !proceso.Mutex[i].Bloqueado {
+		var resultado uint32
+		if asignarMutexLibre(proceso, hiloSolicitante, mutex.Mutex) {
+			resultado = 1
+		}
+		escribirRegistro(hiloSolicitante, mutex.Registro, resultado)
+		enviarTCBCpu(hiloSolicitante)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	mutexEsperasMutex.Lock()
+	proceso.Mutex[i].colaBloqueados = append(proceso.Mutex[i].colaBloqueados, hiloSolicitante)
+	actualizarPCB(proceso)
+
+	contadorEsperasMutex++
+	espera := &esperaMutex{id: contadorEsperasMutex, mutex: mutex.Mutex, registro: mutex.Registro}
+	espera.timer = time.AfterFunc(time.Duration(mutex.Tiempo)*time.Millisecond, func() {
+		vencerEsperaMutex(hiloSolicitante, espera.id)
+	})
+	esperasMutex[claveHilo{pid, tid}] = espera
+	mutexEsperasMutex.Unlock()
+
+	quitarExec(hiloSolicitante)
+	encolarBlock(hiloSolicitante, "MUTEX")
+	recalcularHerencia()
+	detectarDeadlock(hiloSolicitante)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// asignarMutexLibre le asigna el mutex al hilo si existe y esta libre
+func asignarMutexLibre(proceso PCB, hilo TCB, mutexNombre string) bool {
+	i, existe := buscarMutex(proceso, mutexNombre)
+	if !existe {
+		slog.Warn("El mutex no existe", slog.String("mutex", mutexNombre))
+		return false
+	}
+	if proceso.Mutex[i].Bloqueado {
+		return false
+	}
+	proceso.Mutex[i].Bloqueado = true
+	proceso.Mutex[i].HiloUsando = hilo.Tid
+	actualizarPCB(proceso)
+	return true
+}
+
+func vencerEsperaMutex(hilo TCB, id int) {
+	mutexEsperasMutex.Lock()
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	espera, ok := esperasMutex[clave]
+	if !ok || espera.id != id {
+		// el hilo consiguio el mutex o finalizo antes del vencimiento
+		mutexEsperasMutex.Unlock()
+		return
+	}
+	delete(esperasMutex, clave)
+
+	proceso, _ := getPCB(hilo.Pid)
+	if i, existe := buscarMutex(proceso, espera.mutex); existe {
+		proceso.Mutex[i].colaBloqueados = eliminarHiloCola(proceso.Mutex[i].colaBloqueados, hilo)
+		actualizarPCB(proceso)
+	}
+	mutexEsperasMutex.Unlock()
+	recalcularHerencia()
+
+	log.Printf("## (<PID %d>:<TID %d>) - Se vence la espera del mutex %s ##", hilo.Pid, hilo.Tid, espera.mutex)
+
+	escribirRegistro(hilo, espera.registro, 0)
+
+	mutexColaBlockHilo.Lock()
+	hiloBloqueado, err := buscarPorPidYTid(colaBlockHilo, hilo.Pid, hilo.Tid)
+	mutexColaBlockHilo.Unlock()
+	if err != nil {
+		return
+	}
+	quitarBlock(hiloBloqueado)
+	encolarReady(hiloBloqueado)
+}
+
+// tomarEsperaMutex da por terminada la espera con tiempo del hilo al que se le
+// asigno el mutex. Requiere mutexEsperasMutex tomado.
+func tomarEsperaMutex(hilo TCB) (esperaMutex, bool) {
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	espera, ok := esperasMutex[clave]
+	if !ok {
+		return esperaMutex{}, false
+	}
+	espera.timer.Stop()
+	delete(esperasMutex, clave)
+	return *espera, true
+}
+
+func cancelarEsperaMutex(hilo TCB) {
+	mutexEsperasMutex.Lock()
+	tomarEsperaMutex(hilo)
+	mutexEsperasMutex.Unlock()
+}
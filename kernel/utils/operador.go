@@ -0,0 +1,301 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+/*---------------------- API DEL OPERADOR ----------------------*/
+
+// Endpoints para que un operador o un driver de pruebas cree, finalice,
+// suspenda y reanude procesos sin pasar por una syscall de la CPU.
+
+type SolicitudProceso struct {
+	Path       string                `json:"path"`
+	Size       int                   `json:"size"`
+	Prioridad  int                   `json:"prioridad"`
+	TiempoReal *ParametrosTiempoReal `json:"tiempoReal,omitempty"`
+}
+
+type ProcesoCreado struct {
+	Pid int `json:"pid"`
+}
+
+// Hilos retenidos en BLOCK por la suspension de su proceso, por pid. Un
+// proceso esta suspendido mientras tenga una entrada, aunque no retenga hilos.
+var procesosSuspendidos = make(map[int][]int)
+var mutexProcesosSuspendidos sync.Mutex
+
+// POST /procesos
+func SometerProceso(w http.ResponseWriter, r *http.Request) {
+	var solicitud SolicitudProceso
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&solicitud)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if solicitud.Path == "" || solicitud.Size < 0 || solicitud.Prioridad < 0 {
+		http.Error(w, "path, size y prioridad invalidos", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("## Operador - Solicita crear proceso - Path: %s - Tamaño: %d - Prioridad: %d ##", solicitud.Path, solicitud.Size, solicitud.Prioridad)
+
+	var pid int
+	if solicitud.TiempoReal != nil {
+		pid, err = iniciarProcesoTiempoReal(solicitud.Path, solicitud.Size, solicitud.Prioridad, *solicitud.TiempoReal, sinPadre)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	} else {
+		pid = iniciarProceso(solicitud.Path, solicitud.Size, solicitud.Prioridad, sinPadre)
+	}
+
+	responderJSON(w, ProcesoCreado{Pid: pid})
+}
+
+// DELETE /procesos/{pid}
+func MatarProceso(w http.ResponseWriter, r *http.Request) {
+	pid, err := strconv.Atoi(r.PathValue("pid"))
+	if err != nil {
+		http.Error(w, "pid invalido", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("## Operador - Solicita finalizar el proceso %d ##", pid)
+
+	err = matarProceso(pid, EstadoSalidaFinalizado)
+	if errors.Is(err, errProcesoInexistente) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+var errProcesoInexistente = errors.New("no existe el proceso")
+var errProcesoSuspendido = errors.New("proceso ya suspendido")
+var errProcesoNoSuspendido = errors.New("proceso no suspendido")
+
+// matarProceso finaliza un proceso desde afuera, interrumpiendo a las CPUs que ejecutan sus hilos
+func matarProceso(pid int, estado uint32) error {
+	// Un proceso que todavia no entro a memoria solo se saca de la cola
+	if proceso, ok := getProcesoSinIniciar(pid); ok {
+		quitarProcesoSinIniciar(pid)
+		encolarProcesoExit(proceso.PCB)
+		tiempoReal.quitarProceso(pid)
+		finalizarEnArbol(pid, estado)
+		return nil
+	}
+
+	if _, err := getPCB(pid); err != nil {
+		return fmt.Errorf("%w %d", errProcesoInexistente, pid)
+	}
+
+	for _, tcb := range getHilosEnExec(pid) {
+		go enviarInterrupcion(tcb.Pid, tcb.Tid, "Finalizacion")
+	}
+
+	mutexProcesosSuspendidos.Lock()
+	delete(procesosSuspendidos, pid)
+	mutexProcesosSuspendidos.Unlock()
+
+	return exitProcess(pid, estado)
+}
+
+// DELETE /procesos/{pid}/hilos/{tid}
+func CancelarHiloOperador(w http.ResponseWriter, r *http.Request) {
+	pid, errPid := strconv.Atoi(r.PathValue("pid"))
+	tid, errTid := strconv.Atoi(r.PathValue("tid"))
+	if errPid != nil || errTid != nil {
+		http.Error(w, "pid o tid invalido", http.StatusBadRequest)
+		return
+	}
+
+	pcb, err := getPCB(pid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !contieneTid(pcb.Tid, tid) {
+		http.Error(w, fmt.Sprintf("no existe el hilo %d del proceso %d", tid, pid), http.StatusNotFound)
+		return
+	}
+
+	log.Printf("## Operador - Solicita cancelar el hilo (<PID %d>:<TID %d>) ##", pid, tid)
+
+	if isInExec(getTCB(pid, tid)) {
+		go enviarInterrupcion(pid, tid, "Finalizacion")
+	}
+
+	if err := exitHilo(pid, tid, CodigoSalidaCancelado); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	admitirProcesos()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// POST /procesos/{pid}/suspender
+func SuspenderProceso(w http.ResponseWriter, r *http.Request) {
+	pid, err := strconv.Atoi(r.PathValue("pid"))
+	if err != nil {
+		http.Error(w, "pid invalido", http.StatusBadRequest)
+		return
+	}
+
+	err = suspenderProceso(pid)
+	if errors.Is(err, errProcesoSuspendido) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func suspenderProceso(pid int) error {
+	if _, err := getPCB(pid); err != nil {
+		return err
+	}
+
+	mutexProcesosSuspendidos.Lock()
+	if _, suspendido := procesosSuspendidos[pid]; suspendido {
+		mutexProcesosSuspendidos.Unlock()
+		return fmt.Errorf("%w: %d", errProcesoSuspendido, pid)
+	}
+	procesosSuspendidos[pid] = []int{}
+	mutexProcesosSuspendidos.Unlock()
+
+	log.Printf("## (<PID %d>) Se suspende el proceso ##", pid)
+
+	// Los hilos en READY se retienen ya, los que ejecutan cuando la CPU los devuelva
+	mutexColaReadyHilo.Lock()
+	ready := append([]TCB(nil), colaReadyHilo...)
+	mutexColaReadyHilo.Unlock()
+	for _, tcb := range ready {
+		if tcb.Pid == pid {
+			quitarReady(tcb)
+			retenerSiSuspendido(tcb)
+		}
+	}
+	for _, tcb := range getHilosEnExec(pid) {
+		go enviarInterrupcion(tcb.Pid, tcb.Tid, "Suspension")
+	}
+	return nil
+}
+
+// POST /procesos/{pid}/reanudar
+func ReanudarProceso(w http.ResponseWriter, r *http.Request) {
+	pid, err := strconv.Atoi(r.PathValue("pid"))
+	if err != nil {
+		http.Error(w, "pid invalido", http.StatusBadRequest)
+		return
+	}
+
+	err = reanudarProceso(pid)
+	if errors.Is(err, errProcesoNoSuspendido) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func reanudarProceso(pid int) error {
+	if _, err := getPCB(pid); err != nil {
+		return err
+	}
+
+	mutexProcesosSuspendidos.Lock()
+	retenidos, suspendido := procesosSuspendidos[pid]
+	delete(procesosSuspendidos, pid)
+	mutexProcesosSuspendidos.Unlock()
+
+	if !suspendido {
+		return fmt.Errorf("%w: %d", errProcesoNoSuspendido, pid)
+	}
+
+	log.Printf("## (<PID %d>) Se reanuda el proceso ##", pid)
+
+	for _, tid := range retenidos {
+		mutexColaBlockHilo.Lock()
+		tcb, err := buscarPorPidYTid(colaBlockHilo, pid, tid)
+		mutexColaBlockHilo.Unlock()
+		if err != nil {
+			// el hilo se finalizo mientras el proceso estaba suspendido
+			continue
+		}
+		quitarBlock(tcb)
+		encolarReady(tcb)
+	}
+	return nil
+}
+
+// retenerSiSuspendido manda a BLOCK al hilo que iba a pasar a READY si su proceso esta suspendido
+func retenerSiSuspendido(tcb TCB) bool {
+	mutexProcesosSuspendidos.Lock()
+	retenidos, suspendido := procesosSuspendidos[tcb.Pid]
+	if suspendido {
+		procesosSuspendidos[tcb.Pid] = append(retenidos, tcb.Tid)
+	}
+	mutexProcesosSuspendidos.Unlock()
+
+	if suspendido {
+		encolarBlock(tcb, "SUSPENSION")
+	}
+	return suspendido
+}
+
+func getProcesoSinIniciar(pid int) (Proceso, bool) {
+	mutexProcesosSinIniciar.Lock()
+	defer mutexProcesosSinIniciar.Unlock()
+
+	for _, proceso := range colaProcesosSinIniciar {
+		if proceso.PCB.Pid == pid {
+			return proceso, true
+		}
+	}
+	return Proceso{}, false
+}
+
+func getHilosEnExec(pid int) []TCB {
+	mutexColaExecHilo.Lock()
+	defer mutexColaExecHilo.Unlock()
+
+	var hilos []TCB
+	for _, tcb := range colaExecHilo {
+		if tcb.Pid == pid {
+			hilos = append(hilos, tcb)
+		}
+	}
+	return hilos
+}
+
+func contieneTid(tids []int, tid int) bool {
+	for _, t := range tids {
+		if t == tid {
+			return true
+		}
+	}
+	return false
+}
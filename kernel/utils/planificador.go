@@ -0,0 +1,623 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sisoputnfrba/tp-golang/kernel/globals"
+)
+
+/*---------------------- PLANIFICADOR DE CORTO PLAZO ----------------------*/
+
+// Scheduler es la estrategia del planificador de corto plazo: decide que hilo
+// de READY pasa a EXEC, si un hilo en READY debe desalojar al que esta
+// ejecutando y cuanto quantum recibe cada hilo despachado.
+type Scheduler interface {
+	Nombre() string
+	// Elegir devuelve el proximo hilo a ejecutar. Nunca recibe una cola vacia
+	// y no debe modificarla.
+	Elegir(colaReady []TCB) TCB
+	// Desalojar indica si candidato tiene que desalojar al hilo en ejecucion.
+	Desalojar(candidato TCB, ejecutando TCB) bool
+	// Quantum devuelve el quantum en milisegundos del hilo, 0 si no tiene.
+	Quantum(hilo TCB) int
+}
+
+// schedulerConRetroalimentacion lo implementan los algoritmos que ajustan el
+// estado del hilo segun como termino su rafaga de CPU.
+type schedulerConRetroalimentacion interface {
+	FinDeRafaga(hilo TCB, rafaga Rafaga)
+}
+
+// Rafaga describe el paso de un hilo por la CPU, desde que se despacha hasta que la CPU devuelve el control
+type Rafaga struct {
+	Duracion     time.Duration
+	Desalojado   bool // el hilo volvio a READY por una interrupcion
+	AgotoQuantum bool
+}
+
+// schedulerConEstadoPorHilo lo implementan los algoritmos que guardan datos de
+// cada hilo, para descartarlos cuando el hilo finaliza.
+type schedulerConEstadoPorHilo interface {
+	Olvidar(hilo TCB)
+}
+
+// schedulerConCheckpoint lo implementan los algoritmos cuyo estado por hilo se
+// guarda en el checkpoint, para no perderlo al restaurar el kernel.
+type schedulerConCheckpoint interface {
+	Exportar() (json.RawMessage, error)
+	Importar(estado json.RawMessage) error
+}
+
+// schedulerConBloqueo lo implementan los algoritmos que tratan distinto a los
+// hilos que vuelven a READY despues de bloquearse.
+type schedulerConBloqueo interface {
+	Bloqueado(hilo TCB)
+}
+
+// schedulerConPrioridadEfectiva lo implementan los algoritmos cuya prioridad
+// cambia mientras el hilo espera en READY, para poder mostrarla en los logs.
+type schedulerConPrioridadEfectiva interface {
+	PrioridadEfectiva(hilo TCB) int
+}
+
+// Eventos que despiertan al planificador
+const (
+	EventoHiloReady       string = "HILO_READY"
+	EventoCpuLibre        string = "CPU_LIBRE"
+	EventoFinCompactacion string = "FIN_COMPACTACION"
+	EventoFinQuantum      string = "FIN_QUANTUM"
+	EventoEnvejecimiento  string = "ENVEJECIMIENTO"
+	EventoReanudacion     string = "REANUDACION"
+	EventoHerencia        string = "HERENCIA_PRIORIDAD"
+)
+
+type Evento struct {
+	Tipo     string
+	Hilo     TCB
+	Cpu      int // CPU a la que se refiere el evento, para CPU_LIBRE y FIN_QUANTUM
+	Despacho int // despacho al que pertenece el evento, solo para FIN_QUANTUM
+}
+
+type Planificador struct {
+	algoritmo Scheduler
+	eventos   chan Evento
+
+	mutex       sync.Mutex
+	compactando bool
+	detenido    bool // el operador detuvo la planificacion desde la consola
+
+	intervaloEnvejecimiento int
+	prioridadesLogueadas    map[claveHilo]int // solo la usa la goroutine del planificador
+	quantumsRetenidos       []Evento          // fines de quantum que llegaron con la planificacion detenida, solo la usa la goroutine del planificador
+}
+
+var planificador *Planificador
+
+func crearScheduler(config *globals.Config) (Scheduler, error) {
+	switch config.AlgoritmoPlanificacion {
+	case "FIFO":
+		return schedulerFIFO{}, nil
+	case "PRIORIDADES":
+		return schedulerPrioridades{envejecimiento: nuevoEnvejecimiento(config)}, nil
+	case "CMN":
+		return nuevoSchedulerColasMultinivel(config), nil
+	case "SJF":
+		return nuevoSchedulerRafagas("SJF", config), nil
+	case "SRT":
+		return nuevoSchedulerRafagas("SRT", config), nil
+	case "FAIR":
+		return nuevoSchedulerFair(config), nil
+	default:
+		return nil, fmt.Errorf("algoritmo de planificacion %s no valido", config.AlgoritmoPlanificacion)
+	}
+}
+
+func nuevoPlanificador(algoritmo Scheduler, intervaloEnvejecimiento int) *Planificador {
+	return &Planificador{
+		algoritmo:               algoritmo,
+		eventos:                 make(chan Evento, 100),
+		intervaloEnvejecimiento: intervaloEnvejecimiento,
+		prioridadesLogueadas:    make(map[claveHilo]int),
+	}
+}
+
+// notificar despierta al planificador. Nunca se debe llamar desde la
+// goroutine del planificador porque el envio puede bloquear.
+func (p *Planificador) notificar(evento Evento) {
+	if p == nil {
+		return
+	}
+	p.eventos <- evento
+}
+
+func (p *Planificador) ejecutar() {
+	log.Printf("## Se inicia el planificador de corto plazo - Algoritmo: %s ##", p.algoritmo.Nombre())
+
+	if _, ok := p.algoritmo.(schedulerConPrioridadEfectiva); ok && p.intervaloEnvejecimiento > 0 {
+		go p.envejecer()
+	}
+
+	for evento := range p.eventos {
+		slog.Debug("Evento de planificacion", slog.String("tipo", evento.Tipo), slog.Int("cpu", evento.Cpu), slog.Int("pid", evento.Hilo.Pid), slog.Int("tid", evento.Hilo.Tid))
+		switch evento.Tipo {
+		case EventoFinQuantum:
+			p.finDeQuantum(evento)
+			continue
+		case EventoEnvejecimiento:
+			p.loguearPrioridadesEfectivas()
+		case EventoReanudacion:
+			p.rearmarQuantums()
+		}
+		p.planificar()
+	}
+}
+
+func (p *Planificador) planificar() {
+	if p.estaCompactando() || p.estaDetenido() {
+		return
+	}
+
+	// Se despacha un hilo por cada CPU libre
+	for cpu := getCpuLibre(); cpu != nil; cpu = getCpuLibre() {
+		candidato, hayCandidato := p.elegir()
+		if !hayCandidato {
+			return
+		}
+		p.despachar(cpu, candidato)
+	}
+
+	candidato, hayCandidato := p.elegir()
+	if !hayCandidato {
+		return
+	}
+	if victima, hayVictima := p.elegirVictima(candidato); hayVictima {
+		if marcarDesalojo(getCpu(victima.Id), victima.despacho, false) {
+			go enviarInterrupcion(victima.hilo.Pid, victima.hilo.Tid, "Prioridades")
+		}
+	}
+}
+
+func (p *Planificador) elegir() (TCB, bool) {
+	mutexColaReadyHilo.Lock()
+	defer mutexColaReadyHilo.Unlock()
+
+	if len(colaReadyHilo) == 0 {
+		return TCB{}, false
+	}
+	// Los hilos de tiempo real se eligen por EDF antes que cualquier otro
+	hilo, ok, normales := tiempoReal.elegir(colaReadyHilo)
+	if ok {
+		return hilo, true
+	}
+	return p.algoritmo.Elegir(normales), true
+}
+
+func (p *Planificador) desalojar(candidato TCB, ejecutando TCB) bool {
+	if tiempoReal.tieneTrabajo(candidato) || tiempoReal.tieneTrabajo(ejecutando) {
+		return tiempoReal.desalojar(candidato, ejecutando)
+	}
+	return p.algoritmo.Desalojar(candidato, ejecutando)
+}
+
+// quantum devuelve 0 para los hilos de tiempo real, que solo dejan la CPU por un deadline mas proximo
+func (p *Planificador) quantum(hilo TCB) int {
+	if tiempoReal.tieneTrabajo(hilo) {
+		return 0
+	}
+	return p.algoritmo.Quantum(hilo)
+}
+
+// elegirVictima devuelve, de las CPUs que el candidato puede desalojar, la que
+// ejecuta al hilo que menos derecho tiene a seguir en la CPU
+func (p *Planificador) elegirVictima(candidato TCB) (Cpu, bool) {
+	var victima Cpu
+	hayVictima := false
+	for _, cpu := range getCpusOcupadas() {
+		if cpu.desalojoPendiente || !p.desalojar(candidato, cpu.hilo) {
+			continue
+		}
+		if !hayVictima || p.desalojar(victima.hilo, cpu.hilo) {
+			victima = cpu
+			hayVictima = true
+		}
+	}
+	return victima, hayVictima
+}
+
+func (p *Planificador) despachar(cpu *Cpu, hilo TCB) {
+	mutexColaReadyHilo.Lock()
+	if _, err := buscarPorPidYTid(colaReadyHilo, hilo.Pid, hilo.Tid); err != nil {
+		// el hilo salio de READY (por ejemplo por un exit) despues de ser elegido
+		mutexColaReadyHilo.Unlock()
+		return
+	}
+	colaReadyHilo = eliminarHiloCola(colaReadyHilo, hilo)
+	mutexColaReadyHilo.Unlock()
+
+	if algoritmo, ok := p.algoritmo.(schedulerConPrioridadEfectiva); ok {
+		log.Printf("## (<PID %d>:<TID %d>) Se despacha el Hilo a la CPU %d - Prioridad efectiva: %d ##", hilo.Pid, hilo.Tid, cpu.Id, algoritmo.PrioridadEfectiva(hilo))
+	} else {
+		log.Printf("## (<PID %d>:<TID %d>) Se despacha el Hilo a la CPU %d ##", hilo.Pid, hilo.Tid, cpu.Id)
+	}
+	borrarIngresoReady(hilo)
+	delete(p.prioridadesLogueadas, claveHilo{hilo.Pid, hilo.Tid})
+
+	despacho := ocuparCpu(cpu, hilo)
+	encolarExec(hilo)
+
+	p.armarQuantum(hilo, cpu.Id, despacho)
+
+	// La CPU responde cuando deja de ejecutar, recien ahi se considera libre
+	var senal *SenalEntregada
+	if pendiente, hay := tomarSenalPendiente(hilo.Pid); hay {
+		log.Printf("## (<PID %d>:<TID %d>) Se entrega la señal %d - Manejador en el PC %d ##", hilo.Pid, hilo.Tid, pendiente.Senal, pendiente.Manejador)
+		senal = &pendiente
+	}
+
+	go func() {
+		enviarDespachoACpu(cpu, hilo, senal)
+		p.finDeRafaga(cpu)
+	}()
+}
+
+func (p *Planificador) armarQuantum(hilo TCB, cpu int, despacho int) {
+	if quantum := p.quantum(hilo); quantum > 0 {
+		time.AfterFunc(time.Duration(quantum)*time.Millisecond, func() {
+			p.notificar(Evento{Tipo: EventoFinQuantum, Hilo: hilo, Cpu: cpu, Despacho: despacho})
+		})
+	}
+}
+
+func (p *Planificador) finDeQuantum(evento Evento) {
+	cpu := getCpu(evento.Cpu)
+	if !isInExec(evento.Hilo) {
+		return
+	}
+	// Con la planificacion detenida no se desaloja, el quantum se vuelve a armar al reanudar
+	if p.estaDetenido() {
+		p.quantumsRetenidos = append(p.quantumsRetenidos, evento)
+		return
+	}
+	if marcarDesalojo(cpu, evento.Despacho, true) {
+		go enviarInterrupcion(evento.Hilo.Pid, evento.Hilo.Tid, "Quantum")
+	}
+}
+
+// finDeRafaga libera la CPU y avisa al algoritmo como termino la rafaga del hilo
+func (p *Planificador) finDeRafaga(cpu *Cpu) {
+	hilo, rafaga := liberarCpu(cpu)
+
+	if isInExec(hilo) {
+		slog.Warn("La CPU devolvio el control pero el hilo sigue en EXEC", slog.Int("cpu", cpu.Id), slog.Int("pid", hilo.Pid), slog.Int("tid", hilo.Tid))
+	}
+	// Si se pidio el desalojo pero el hilo se bloqueo antes de atender la interrupcion, la rafaga termino igual
+	rafaga.Desalojado = rafaga.Desalojado && isInReady(hilo)
+
+	// Si el hilo finalizo durante la rafaga el algoritmo ya lo olvido
+	if tiempoReal.tieneTrabajo(hilo) {
+		tiempoReal.finDeRafaga(hilo, rafaga)
+		// Si el hilo volvio a READY antes de que la CPU respondiera, ya tiene un nuevo trabajo
+		if !rafaga.Desalojado && isInReady(hilo) {
+			tiempoReal.liberar(hilo)
+		}
+	} else if algoritmo, ok := p.algoritmo.(schedulerConRetroalimentacion); ok && !isInExit(hilo) {
+		algoritmo.FinDeRafaga(hilo, rafaga)
+	}
+
+	p.notificar(Evento{Tipo: EventoCpuLibre, Hilo: hilo, Cpu: cpu.Id})
+}
+
+// hiloFinalizado descarta lo que el algoritmo guardaba del hilo
+func (p *Planificador) hiloFinalizado(hilo TCB) {
+	tiempoReal.olvidar(hilo)
+	if p == nil {
+		return
+	}
+	if algoritmo, ok := p.algoritmo.(schedulerConEstadoPorHilo); ok {
+		algoritmo.Olvidar(hilo)
+	}
+}
+
+// hiloBloqueado avisa al algoritmo que el hilo dejo de competir por la CPU
+func (p *Planificador) hiloBloqueado(hilo TCB) {
+	if p == nil {
+		return
+	}
+	if algoritmo, ok := p.algoritmo.(schedulerConBloqueo); ok {
+		algoritmo.Bloqueado(hilo)
+	}
+}
+
+// envejecer despierta al planificador cada intervalo de envejecimiento, porque
+// la prioridad de los hilos en READY cambia aunque no ocurra ningun otro evento
+func (p *Planificador) envejecer() {
+	ticker := time.NewTicker(time.Duration(p.intervaloEnvejecimiento) * time.Millisecond)
+	for range ticker.C {
+		p.notificar(Evento{Tipo: EventoEnvejecimiento})
+	}
+}
+
+func (p *Planificador) loguearPrioridadesEfectivas() {
+	algoritmo := p.algoritmo.(schedulerConPrioridadEfectiva)
+
+	mutexColaReadyHilo.Lock()
+	colaReady := append([]TCB(nil), colaReadyHilo...)
+	mutexColaReadyHilo.Unlock()
+
+	// Se arma de nuevo en cada revision para no guardar hilos que ya salieron de READY
+	prioridadesLogueadas := make(map[claveHilo]int)
+	for _, hilo := range colaReady {
+		clave := claveHilo{hilo.Pid, hilo.Tid}
+		prioridad := algoritmo.PrioridadEfectiva(hilo)
+		prioridadesLogueadas[clave] = prioridad
+
+		anterior, ok := p.prioridadesLogueadas[clave]
+		if (ok && anterior != prioridad) || (!ok && prioridad != hilo.Prioridad) {
+			log.Printf("## (<PID %d>:<TID %d>) Envejecimiento - Prioridad efectiva: %d ##", hilo.Pid, hilo.Tid, prioridad)
+		}
+	}
+	p.prioridadesLogueadas = prioridadesLogueadas
+}
+
+func (p *Planificador) iniciarCompactacion() {
+	p.mutex.Lock()
+	p.compactando = true
+	p.mutex.Unlock()
+}
+
+func (p *Planificador) finalizarCompactacion() {
+	p.mutex.Lock()
+	p.compactando = false
+	p.mutex.Unlock()
+
+	p.notificar(Evento{Tipo: EventoFinCompactacion})
+}
+
+func (p *Planificador) estaCompactando() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.compactando
+}
+
+// detener deja de despachar y desalojar hilos; los que estan en CPU siguen hasta que la devuelvan
+func (p *Planificador) detener() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.detenido {
+		return false
+	}
+	p.detenido = true
+	return true
+}
+
+func (p *Planificador) reanudar() bool {
+	p.mutex.Lock()
+	if !p.detenido {
+		p.mutex.Unlock()
+		return false
+	}
+	p.detenido = false
+	p.mutex.Unlock()
+
+	p.notificar(Evento{Tipo: EventoReanudacion})
+	return true
+}
+
+// rearmarQuantums le da un quantum nuevo a los hilos cuyo quantum vencio con la
+// planificacion detenida. Si el despacho ya termino, el fin de quantum se descarta.
+func (p *Planificador) rearmarQuantums() {
+	for _, evento := range p.quantumsRetenidos {
+		if isInExec(evento.Hilo) {
+			p.armarQuantum(evento.Hilo, evento.Cpu, evento.Despacho)
+		}
+	}
+	p.quantumsRetenidos = nil
+}
+
+func (p *Planificador) estaDetenido() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.detenido
+}
+
+/*---------- ALGORITMOS DE PLANIFICACION ----------*/
+
+// FIFO
+type schedulerFIFO struct{}
+
+func (schedulerFIFO) Nombre() string { return "FIFO" }
+
+func (schedulerFIFO) Elegir(colaReady []TCB) TCB { return colaReady[0] }
+
+func (schedulerFIFO) Desalojar(candidato TCB, ejecutando TCB) bool { return false }
+
+func (schedulerFIFO) Quantum(hilo TCB) int { return 0 }
+
+// PRIORIDADES
+type schedulerPrioridades struct {
+	envejecimiento Envejecimiento
+}
+
+func (schedulerPrioridades) Nombre() string { return "PRIORIDADES" }
+
+func (s schedulerPrioridades) Elegir(colaReady []TCB) TCB {
+	return obtenerHiloMayorPrioridad(colaReady, s.PrioridadEfectiva)
+}
+
+func (s schedulerPrioridades) Desalojar(candidato TCB, ejecutando TCB) bool {
+	return s.PrioridadEfectiva(candidato) < s.PrioridadEfectiva(ejecutando)
+}
+
+func (schedulerPrioridades) Quantum(hilo TCB) int { return 0 }
+
+func (s schedulerPrioridades) PrioridadEfectiva(hilo TCB) int {
+	return aplicarHerencia(hilo, s.envejecimiento.aplicar(hilo, hilo.Prioridad))
+}
+
+// MULTICOLAS
+// Cada nivel tiene su propia cola FIFO de READY con su propio quantum. El nivel
+// inicial de un hilo es su prioridad; con retroalimentacion un hilo que agota el
+// quantum baja un nivel y uno que deja la CPU antes sube uno, sin pasar nunca
+// por encima de su prioridad original. El envejecimiento adelanta de nivel a
+// los hilos que esperan en READY.
+type schedulerColasMultinivel struct {
+	quantum           int
+	quantumPorNivel   map[int]int
+	retroalimentacion bool
+	nivelMaximo       int // 0 indica que no hay limite
+	envejecimiento    Envejecimiento
+
+	mutex   sync.Mutex
+	niveles map[claveHilo]int
+}
+
+type claveHilo struct {
+	Pid int
+	Tid int
+}
+
+func nuevoSchedulerColasMultinivel(config *globals.Config) *schedulerColasMultinivel {
+	return &schedulerColasMultinivel{
+		quantum:           config.Quantum,
+		quantumPorNivel:   config.QuantumPorNivel,
+		retroalimentacion: config.RetroalimentacionCMN,
+		nivelMaximo:       config.NivelMaximoCMN,
+		envejecimiento:    nuevoEnvejecimiento(config),
+		niveles:           make(map[claveHilo]int),
+	}
+}
+
+func (*schedulerColasMultinivel) Nombre() string { return "CMN" }
+
+func (s *schedulerColasMultinivel) Elegir(colaReady []TCB) TCB {
+	colas := s.colasPorNivel(colaReady)
+
+	nivelMasAlto := -1
+	for nivel := range colas {
+		if nivelMasAlto == -1 || nivel < nivelMasAlto {
+			nivelMasAlto = nivel
+		}
+	}
+	return colas[nivelMasAlto][0]
+}
+
+func (s *schedulerColasMultinivel) Desalojar(candidato TCB, ejecutando TCB) bool {
+	return s.PrioridadEfectiva(candidato) < s.PrioridadEfectiva(ejecutando)
+}
+
+// PrioridadEfectiva devuelve el nivel en el que compite el hilo, contando el envejecimiento y la herencia
+func (s *schedulerColasMultinivel) PrioridadEfectiva(hilo TCB) int {
+	return aplicarHerencia(hilo, s.envejecimiento.aplicar(hilo, s.nivel(hilo)))
+}
+
+func (s *schedulerColasMultinivel) Quantum(hilo TCB) int {
+	if quantum, ok := s.quantumPorNivel[s.nivel(hilo)]; ok {
+		return quantum
+	}
+	return s.quantum
+}
+
+func (s *schedulerColasMultinivel) FinDeRafaga(hilo TCB, rafaga Rafaga) {
+	if !s.retroalimentacion {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	nivelActual, ok := s.niveles[clave]
+	if !ok {
+		nivelActual = hilo.Prioridad
+	}
+
+	nuevoNivel := nivelActual
+	if rafaga.AgotoQuantum && (s.nivelMaximo == 0 || nivelActual < s.nivelMaximo) {
+		nuevoNivel++
+	} else if !rafaga.AgotoQuantum && nivelActual > hilo.Prioridad {
+		nuevoNivel--
+	}
+
+	if nuevoNivel != nivelActual {
+		s.niveles[clave] = nuevoNivel
+		log.Printf("## (<PID %d>:<TID %d>) Cambia de nivel en CMN: %d -> %d ##", hilo.Pid, hilo.Tid, nivelActual, nuevoNivel)
+	}
+}
+
+func (s *schedulerColasMultinivel) Olvidar(hilo TCB) {
+	s.mutex.Lock()
+	delete(s.niveles, claveHilo{hilo.Pid, hilo.Tid})
+	s.mutex.Unlock()
+}
+
+type nivelCheckpoint struct {
+	Pid   int `json:"pid"`
+	Tid   int `json:"tid"`
+	Nivel int `json:"nivel"`
+}
+
+func (s *schedulerColasMultinivel) Exportar() (json.RawMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	niveles := []nivelCheckpoint{}
+	for clave, nivel := range s.niveles {
+		niveles = append(niveles, nivelCheckpoint{clave.Pid, clave.Tid, nivel})
+	}
+	return json.Marshal(niveles)
+}
+
+func (s *schedulerColasMultinivel) Importar(estado json.RawMessage) error {
+	var niveles []nivelCheckpoint
+	if err := json.Unmarshal(estado, &niveles); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, nivel := range niveles {
+		s.niveles[claveHilo{nivel.Pid, nivel.Tid}] = nivel.Nivel
+	}
+	return nil
+}
+
+// colasPorNivel arma la cola de cada nivel respetando el orden de llegada a READY
+func (s *schedulerColasMultinivel) colasPorNivel(colaReady []TCB) map[int][]TCB {
+	colas := make(map[int][]TCB)
+	for _, hilo := range colaReady {
+		nivel := s.PrioridadEfectiva(hilo)
+		colas[nivel] = append(colas[nivel], hilo)
+	}
+	return colas
+}
+
+func (s *schedulerColasMultinivel) nivel(hilo TCB) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if nivel, ok := s.niveles[claveHilo{hilo.Pid, hilo.Tid}]; ok {
+		return nivel
+	}
+	return hilo.Prioridad
+}
+
+// obtenerHiloMayorPrioridad devuelve el primer hilo de la cola con el menor
+// numero de prioridad, asi los hilos de igual prioridad respetan el orden FIFO.
+func obtenerHiloMayorPrioridad(colaReady []TCB, prioridad func(TCB) int) TCB {
+	hiloMayorPrioridad := colaReady[0]
+	mayorPrioridad := prioridad(hiloMayorPrioridad)
+	for _, hilo := range colaReady {
+		if p := prioridad(hilo); p < mayorPrioridad {
+			hiloMayorPrioridad = hilo
+			mayorPrioridad = p
+		}
+	}
+	return hiloMayorPrioridad
+}
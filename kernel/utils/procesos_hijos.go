@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+)
+
+/*---------------------- ARBOL DE PROCESOS Y PROCESS_WAIT ----------------------*/
+
+// Cada proceso creado con PROCESS_CREATE es hijo del proceso que hizo la
+// syscall. Cuando un proceso finaliza queda como zombie con su estado de salida
+// hasta que el padre lo recoge con PROCESS_WAIT <pid|ANY> <registro>. Los hijos
+// de un proceso que finaliza pasan a ser hijos del proceso inicial. Los
+// procesos creados por el operador no tienen padre y nadie los espera.
+
+const (
+	EstadoSalidaNormal      uint32 = 0
+	EstadoSalidaSegFault    uint32 = 1
+	EstadoSalidaFinalizado  uint32 = 2 // finalizado por el operador
+	EstadoSalidaDumpFallido uint32 = 3
+	EstadoSalidaSenal       uint32 = 4          // finalizado por una señal
+	CodigoEsperaInvalida    uint32 = 0xFFFFFFFE // PROCESS_WAIT sin hijos que esperar
+	sinPadre                       = -1
+	esperarCualquierHijo           = -1
+)
+
+var nombresEstadoSalida = map[uint32]string{
+	EstadoSalidaNormal:      "NORMAL",
+	EstadoSalidaSegFault:    "SEGMENTATION_FAULT",
+	EstadoSalidaFinalizado:  "FINALIZADO",
+	EstadoSalidaDumpFallido: "DUMP_FALLIDO",
+	EstadoSalidaSenal:       "SENAL",
+}
+
+type EsperaProcesoRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Hijo     int    `json:"hijo"` // -1 para esperar a cualquier hijo
+	Registro string `json:"registro"`
+}
+
+type nodoProceso struct {
+	padre      int
+	finalizado bool
+	estado     uint32
+}
+
+type esperaProceso struct {
+	hilo     TCB
+	hijo     int
+	registro string
+}
+
+var pidInicial = sinPadre
+
+// Los procesos se quitan del arbol cuando finalizan sin padre o cuando el padre los recoge
+var arbolProcesos = make(map[int]*nodoProceso)
+var esperasProceso = make(map[claveHilo]esperaProceso)
+var mutexArbolProcesos sync.Mutex
+
+func registrarProceso(pid int, padre int) {
+	mutexArbolProcesos.Lock()
+	arbolProcesos[pid] = &nodoProceso{padre: padre}
+	mutexArbolProcesos.Unlock()
+}
+
+func padreDe(pid int) (int, bool) {
+	mutexArbolProcesos.Lock()
+	defer mutexArbolProcesos.Unlock()
+
+	nodo, ok := arbolProcesos[pid]
+	if !ok || nodo.padre == sinPadre {
+		return 0, false
+	}
+	return nodo.padre, true
+}
+
+func EsperarProceso(w http.ResponseWriter, r *http.Request) {
+	var espera EsperaProcesoRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&espera)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := espera.Pid
+	tid := espera.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <PROCESS_WAIT> ##", pid, tid)
+
+	hiloSolicitante := getTCB(pid, tid)
+
+	mutexArbolProcesos.Lock()
+	hijo, estado, recogido := recogerHijo(pid, espera.Hijo)
+	if !recogido && tieneHijo(pid, espera.Hijo) {
+		// El hilo pasa a BLOCK antes de soltar el arbol, asi finalizarEnArbol
+		// siempre encuentra bloqueado al hilo que despierta
+		quitarExec(hiloSolicitante)
+		encolarBlock(hiloSolicitante, "PROCESS_WAIT")
+		esperasProceso[claveHilo{pid, tid}] = esperaProceso{hilo: hiloSolicitante, hijo: espera.Hijo, registro: espera.Registro}
+		mutexArbolProcesos.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	mutexArbolProcesos.Unlock()
+
+	if recogido {
+		log.Printf("## (<PID:%d>:<TID:%d>) - Recoge al proceso hijo %d - Estado de salida: %s ##", pid, tid, hijo, nombresEstadoSalida[estado])
+	} else {
+		log.Printf("## (<PID:%d>:<TID:%d>) - No hay procesos hijos para esperar ##", pid, tid)
+		estado = CodigoEsperaInvalida
+	}
+	escribirRegistro(hiloSolicitante, espera.Registro, estado)
+	enviarTCBCpu(hiloSolicitante)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// recogerHijo quita del arbol al primer hijo finalizado que coincida con la espera.
+// Requiere mutexArbolProcesos tomado.
+func recogerHijo(padre int, hijo int) (int, uint32, bool) {
+	for pid, nodo := range arbolProcesos {
+		if nodo.padre == padre && nodo.finalizado && (hijo == esperarCualquierHijo || hijo == pid) {
+			delete(arbolProcesos, pid)
+			return pid, nodo.estado, true
+		}
+	}
+	return 0, 0, false
+}
+
+// tieneHijo indica si el padre tiene un hijo que todavia no finalizo. Requiere mutexArbolProcesos tomado.
+func tieneHijo(padre int, hijo int) bool {
+	for pid, nodo := range arbolProcesos {
+		if nodo.padre == padre && !nodo.finalizado && (hijo == esperarCualquierHijo || hijo == pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// finalizarEnArbol deja al proceso como zombie, pasa sus hijos al proceso
+// inicial y despierta a los hilos que esperaban a alguno de ellos
+func finalizarEnArbol(pid int, estado uint32) {
+	var despertados []esperaProceso
+	var estados []uint32
+
+	mutexArbolProcesos.Lock()
+	nodo, ok := arbolProcesos[pid]
+	if !ok || nodo.finalizado {
+		mutexArbolProcesos.Unlock()
+		return
+	}
+	nodo.finalizado = true
+	nodo.estado = estado
+
+	// Las esperas de los hilos del proceso ya no tienen quien las reciba
+	for clave := range esperasProceso {
+		if clave.Pid == pid {
+			delete(esperasProceso, clave)
+		}
+	}
+
+	padresAfectados := []int{nodo.padre}
+	for hijo, nodoHijo := range arbolProcesos {
+		if nodoHijo.padre != pid {
+			continue
+		}
+		if pid == pidInicial || !procesoVivo(pidInicial) {
+			nodoHijo.padre = sinPadre
+		} else {
+			nodoHijo.padre = pidInicial
+			log.Printf("## (<PID %d>) Queda huerfano, pasa a ser hijo del proceso %d ##", hijo, pidInicial)
+		}
+		if nodoHijo.finalizado && nodoHijo.padre == sinPadre {
+			delete(arbolProcesos, hijo)
+		}
+	}
+	if nodo.padre != pidInicial {
+		padresAfectados = append(padresAfectados, pidInicial)
+	}
+	if nodo.padre == sinPadre || !procesoVivo(nodo.padre) {
+		delete(arbolProcesos, pid)
+	}
+
+	for _, padre := range padresAfectados {
+		for clave, espera := range esperasProceso {
+			if clave.Pid != padre {
+				continue
+			}
+			hijo, estadoHijo, recogido := recogerHijo(padre, espera.hijo)
+			if recogido {
+				log.Printf("## (<PID:%d>:<TID:%d>) - Recoge al proceso hijo %d - Estado de salida: %s ##", clave.Pid, clave.Tid, hijo, nombresEstadoSalida[estadoHijo])
+			} else if !tieneHijo(padre, espera.hijo) {
+				// el hijo esperado fue finalizado sin padre o el proceso ya no tiene hijos
+				estadoHijo = CodigoEsperaInvalida
+			} else {
+				continue
+			}
+			delete(esperasProceso, clave)
+			despertados = append(despertados, espera)
+			estados = append(estados, estadoHijo)
+		}
+	}
+	mutexArbolProcesos.Unlock()
+
+	log.Printf("## (<PID %d>) Finaliza el proceso - Estado de salida: %s ##", pid, nombresEstadoSalida[estado])
+
+	for i, espera := range despertados {
+		escribirRegistro(espera.hilo, espera.registro, estados[i])
+
+		mutexColaBlockHilo.Lock()
+		hiloBloqueado, err := buscarPorPidYTid(colaBlockHilo, espera.hilo.Pid, espera.hilo.Tid)
+		mutexColaBlockHilo.Unlock()
+		if err != nil {
+			continue
+		}
+		quitarBlock(hiloBloqueado)
+		encolarReady(hiloBloqueado)
+	}
+}
+
+// procesoVivo requiere mutexArbolProcesos tomado
+func procesoVivo(pid int) bool {
+	nodo, ok := arbolProcesos[pid]
+	return ok && !nodo.finalizado
+}
+
+func cancelarEsperaProceso(hilo TCB) {
+	mutexArbolProcesos.Lock()
+	delete(esperasProceso, claveHilo{hilo.Pid, hilo.Tid})
+	mutexArbolProcesos.Unlock()
+}
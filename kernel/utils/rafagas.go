@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/sisoputnfrba/tp-golang/kernel/globals"
+)
+
+/*---------- SJF / SRT ----------*/
+
+// schedulerRafagas elige al hilo con la proxima rafaga estimada mas corta. La
+// estimacion es un promedio exponencial de las rafagas anteriores:
+// estimacion = alfa * rafagaReal + (1 - alfa) * estimacionAnterior.
+// SJF no desaloja; SRT desaloja cuando llega a READY un hilo con menos tiempo
+// restante que el que esta ejecutando.
+type schedulerRafagas struct {
+	nombre            string
+	desalojar         bool
+	alfa              float64
+	estimacionInicial float64
+
+	mutex        sync.Mutex
+	estimaciones map[claveHilo]*estimacionRafaga
+}
+
+type estimacionRafaga struct {
+	estimada  float64       // proxima rafaga estimada, en ms
+	ejecutado time.Duration // tiempo ya ejecutado de la rafaga actual si el hilo fue desalojado
+}
+
+func nuevoSchedulerRafagas(nombre string, config *globals.Config) *schedulerRafagas {
+	alfa := config.Alfa
+	if alfa <= 0 || alfa > 1 {
+		alfa = 0.5
+	}
+	estimacionInicial := config.EstimacionInicial
+	if estimacionInicial <= 0 {
+		estimacionInicial = 1000
+	}
+
+	return &schedulerRafagas{
+		nombre:            nombre,
+		desalojar:         nombre == "SRT",
+		alfa:              alfa,
+		estimacionInicial: float64(estimacionInicial),
+		estimaciones:      make(map[claveHilo]*estimacionRafaga),
+	}
+}
+
+func (s *schedulerRafagas) Nombre() string { return s.nombre }
+
+func (s *schedulerRafagas) Elegir(colaReady []TCB) TCB {
+	elegido := colaReady[0]
+	menorRestante := s.restante(elegido)
+	for _, hilo := range colaReady {
+		if restante := s.restante(hilo); restante < menorRestante {
+			elegido = hilo
+			menorRestante = restante
+		}
+	}
+	return elegido
+}
+
+func (s *schedulerRafagas) Desalojar(candidato TCB, ejecutando TCB) bool {
+	if !s.desalojar {
+		return false
+	}
+	return s.restante(candidato) < s.restante(ejecutando)
+}
+
+func (s *schedulerRafagas) Quantum(hilo TCB) int { return 0 }
+
+func (s *schedulerRafagas) FinDeRafaga(hilo TCB, rafaga Rafaga) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	estimacion := s.estimacion(hilo)
+
+	// Un hilo desalojado no termino su rafaga, solo se acumula lo que ejecuto
+	if rafaga.Desalojado {
+		estimacion.ejecutado += rafaga.Duracion
+		return
+	}
+
+	rafagaReal := estimacion.ejecutado + rafaga.Duracion
+	anterior := estimacion.estimada
+	estimacion.estimada = s.alfa*float64(rafagaReal.Milliseconds()) + (1-s.alfa)*anterior
+	estimacion.ejecutado = 0
+
+	log.Printf("## (<PID %d>:<TID %d>) Rafaga real: %d ms - Estimacion anterior: %.2f ms - Proxima estimacion: %.2f ms ##", hilo.Pid, hilo.Tid, rafagaReal.Milliseconds(), anterior, estimacion.estimada)
+}
+
+func (s *schedulerRafagas) Olvidar(hilo TCB) {
+	s.mutex.Lock()
+	delete(s.estimaciones, claveHilo{hilo.Pid, hilo.Tid})
+	s.mutex.Unlock()
+}
+
+type estimacionCheckpoint struct {
+	Pid       int           `json:"pid"`
+	Tid       int           `json:"tid"`
+	Estimada  float64       `json:"estimada"`
+	Ejecutado time.Duration `json:"ejecutado"`
+}
+
+func (s *schedulerRafagas) Exportar() (json.RawMessage, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	estimaciones := []estimacionCheckpoint{}
+	for clave, estimacion := range s.estimaciones {
+		estimaciones = append(estimaciones, estimacionCheckpoint{clave.Pid, clave.Tid, estimacion.estimada, estimacion.ejecutado})
+	}
+	return json.Marshal(estimaciones)
+}
+
+func (s *schedulerRafagas) Importar(estado json.RawMessage) error {
+	var estimaciones []estimacionCheckpoint
+	if err := json.Unmarshal(estado, &estimaciones); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, estimacion := range estimaciones {
+		s.estimaciones[claveHilo{estimacion.Pid, estimacion.Tid}] = &estimacionRafaga{estimada: estimacion.Estimada, ejecutado: estimacion.Ejecutado}
+	}
+	return nil
+}
+
+// restante devuelve los ms que se estima que le faltan al hilo para terminar su rafaga
+func (s *schedulerRafagas) restante(hilo TCB) float64 {
+	enCpu := tiempoEnCpu(hilo)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	estimacion := s.estimacion(hilo)
+	return estimacion.estimada - float64((estimacion.ejecutado + enCpu).Milliseconds())
+}
+
+// estimacion devuelve la estimacion del hilo, creandola si es su primera rafaga. Requiere el mutex tomado.
+func (s *schedulerRafagas) estimacion(hilo TCB) *estimacionRafaga {
+	clave := claveHilo{hilo.Pid, hilo.Tid}
+	estimacion, ok := s.estimaciones[clave]
+	if !ok {
+		estimacion = &estimacionRafaga{estimada: s.estimacionInicial}
+		s.estimaciones[clave] = estimacion
+	}
+	return estimacion
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+)
+
+/*---------------------- SEMAFOROS ----------------------*/
+
+// Semaforo contador de un proceso. El valor nunca es negativo: si un hilo hace
+// SEM_WAIT con valor 0 se bloquea en colaBloqueados, y un SEM_POST despierta
+// al primero de la cola en lugar de incrementar el valor.
+type Semaforo struct {
+	Nombre         string
+	Valor          int
+	colaBloqueados []TCB
+}
+
+type SemaforoRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Semaforo string `json:"semaforo"`
+	Valor    int    `json:"valor"`
+}
+
+func CrearSemaforo(w http.ResponseWriter, r *http.Request) {
+	var semaforo SemaforoRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&semaforo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := semaforo.Pid
+	tid := semaforo.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SEM_CREATE> ##", pid, tid)
+
+	pcb, _ := getPCB(pid)
+	if _, existe := buscarSemaforo(pcb, semaforo.Semaforo); existe {
+		slog.Warn("El semaforo ya existe", slog.String("semaforo", semaforo.Semaforo))
+	} else if semaforo.Valor < 0 {
+		slog.Warn("El valor inicial del semaforo no puede ser negativo", slog.String("semaforo", semaforo.Semaforo))
+	} else {
+		pcb.Semaforos = append(pcb.Semaforos, semaforoCreate(semaforo.Semaforo, semaforo.Valor))
+		actualizarPCB(pcb)
+	}
+
+	tcbActual := getTCB(pid, tid)
+	enviarTCBCpu(tcbActual)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func EsperarSemaforo(w http.ResponseWriter, r *http.Request) {
+	var semaforo SemaforoRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&semaforo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := semaforo.Pid
+	tid := semaforo.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SEM_WAIT> ##", pid, tid)
+
+	proceso, _ := getPCB(pid)
+	hiloSolicitante := getTCB(pid, tid)
+
+	waitSemaforo(proceso, hiloSolicitante, semaforo.Semaforo)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func SenalarSemaforo(w http.ResponseWriter, r *http.Request) {
+	var semaforo SemaforoRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&semaforo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := semaforo.Pid
+	tid := semaforo.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SEM_POST> ##", pid, tid)
+
+	proceso, _ := getPCB(pid)
+	hiloSolicitante := getTCB(pid, tid)
+
+	postSemaforo(proceso, semaforo.Semaforo)
+
+	enviarTCBCpu(hiloSolicitante)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func waitSemaforo(proceso PCB, hiloSolicitante TCB, semaforoNombre string) {
+	i, existe := buscarSemaforo(proceso, semaforoNombre)
+	if !existe {
+		slog.Warn("El semaforo no existe", slog.String("semaforo", semaforoNombre))
+		enviarTCBCpu(hiloSolicitante)
+		return
+	}
+	semaforo := proceso.Semaforos[i]
+
+	if semaforo.Valor > 0 {
+		semaforo.Valor--
+		proceso.Semaforos[i] = semaforo
+		actualizarPCB(proceso)
+		enviarTCBCpu(hiloSolicitante)
+		return
+	}
+
+	semaforo.colaBloqueados = append(semaforo.colaBloqueados, hiloSolicitante)
+	proceso.Semaforos[i] = semaforo
+	actualizarPCB(proceso)
+
+	quitarExec(hiloSolicitante)
+	encolarBlock(hiloSolicitante, "SEMAFORO")
+}
+
+func postSemaforo(proceso PCB, semaforoNombre string) {
+	i, existe := buscarSemaforo(proceso, semaforoNombre)
+	if !existe {
+		slog.Warn("El semaforo no existe", slog.String("semaforo", semaforoNombre))
+		return
+	}
+	semaforo := proceso.Semaforos[i]
+
+	if len(semaforo.colaBloqueados) == 0 {
+		semaforo.Valor++
+		proceso.Semaforos[i] = semaforo
+		actualizarPCB(proceso)
+		return
+	}
+
+	hiloDesbloqueado := semaforo.colaBloqueados[0]
+	semaforo.colaBloqueados = semaforo.colaBloqueados[1:]
+	proceso.Semaforos[i] = semaforo
+	actualizarPCB(proceso)
+
+	quitarBlock(hiloDesbloqueado)
+	encolarReady(hiloDesbloqueado)
+}
+
+func semaforoCreate(nombreSemaforo string, valorInicial int) Semaforo {
+	return Semaforo{
+		Nombre:         nombreSemaforo,
+		Valor:          valorInicial,
+		colaBloqueados: []TCB{},
+	}
+}
+
+func buscarSemaforo(proceso PCB, semaforoNombre string) (int, bool) {
+	for i, semaforo := range proceso.Semaforos {
+		if semaforo.Nombre == semaforoNombre {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// quitarDeColasDeSemaforos saca al hilo de las colas de bloqueados de los semaforos del proceso
+func quitarDeColasDeSemaforos(proceso PCB, hilo TCB) {
+	for i := range proceso.Semaforos {
+		proceso.Semaforos[i].colaBloqueados = eliminarHiloCola(proceso.Semaforos[i].colaBloqueados, hilo)
+	}
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+/*---------------------- SEÑALES ----------------------*/
+
+// SIGNAL <pid> <señal> manda una señal asincronica a un proceso. Si el proceso
+// registro un manejador con SIGNAL_HANDLER <señal> <pc>, la señal queda
+// pendiente y se entrega en el proximo despacho de cualquiera de sus hilos: la
+// CPU guarda el contexto del hilo en memoria y salta al PC del manejador, que
+// vuelve con SIGNAL_RETURN. Si no hay manejador se aplica la accion por defecto.
+// KILL y STOP no se pueden manejar.
+
+const (
+	SenalTerminar  = 15
+	SenalMatar     = 9
+	SenalUsuario1  = 10
+	SenalUsuario2  = 12
+	SenalContinuar = 18
+	SenalDetener   = 19
+)
+
+const (
+	AccionTerminar  string = "TERMINAR"
+	AccionIgnorar   string = "IGNORAR"
+	AccionDetener   string = "DETENER"
+	AccionContinuar string = "CONTINUAR"
+)
+
+var accionesPorDefecto = map[int]string{
+	SenalTerminar:  AccionTerminar,
+	SenalMatar:     AccionTerminar,
+	SenalUsuario1:  AccionIgnorar,
+	SenalUsuario2:  AccionIgnorar,
+	SenalContinuar: AccionContinuar,
+	SenalDetener:   AccionDetener,
+}
+
+type SenalRequest struct {
+	Pid     int    `json:"pid"`
+	Tid     int    `json:"tid"`
+	Destino int    `json:"destino"` // solo SIGNAL
+	Senal   int    `json:"senal"`
+	Pc      uint32 `json:"pc"` // solo SIGNAL_HANDLER
+}
+
+// Se manda a la CPU junto con el hilo cuando hay una señal para atender
+type SenalEntregada struct {
+	Senal     int    `json:"senal"`
+	Manejador uint32 `json:"manejador"`
+}
+
+var manejadoresSenal = make(map[int]map[int]uint32) // pid -> señal -> pc del manejador
+var senalesPendientes = make(map[int][]int)
+var mutexSenales sync.Mutex
+
+func RegistrarManejadorSenal(w http.ResponseWriter, r *http.Request) {
+	var senal SenalRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&senal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := senal.Pid
+	tid := senal.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SIGNAL_HANDLER> ##", pid, tid)
+
+	if _, existe := accionesPorDefecto[senal.Senal]; !existe || senal.Senal == SenalMatar || senal.Senal == SenalDetener {
+		slog.Warn("La señal no se puede manejar", slog.Int("senal", senal.Senal))
+	} else {
+		mutexSenales.Lock()
+		if manejadoresSenal[pid] == nil {
+			manejadoresSenal[pid] = make(map[int]uint32)
+		}
+		manejadoresSenal[pid][senal.Senal] = senal.Pc
+		mutexSenales.Unlock()
+	}
+
+	tcbActual := getTCB(pid, tid)
+	enviarTCBCpu(tcbActual)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func EnviarSenal(w http.ResponseWriter, r *http.Request) {
+	var senal SenalRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&senal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pid := senal.Pid
+	tid := senal.Tid
+
+	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <SIGNAL> ##", pid, tid)
+
+	if err := enviarSenal(senal.Destino, senal.Senal); err != nil {
+		slog.Warn("No se pudo enviar la señal", slog.Int("destino", senal.Destino), slog.Int("senal", senal.Senal), slog.Any("error", err))
+	}
+
+	// El hilo pudo haber finalizado si el proceso se mando la señal a si mismo
+	tcbActual := getTCB(pid, tid)
+	if isInExec(tcbActual) {
+		enviarTCBCpu(tcbActual)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func enviarSenal(pid int, senal int) error {
+	accion, existe := accionesPorDefecto[senal]
+	if !existe {
+		slog.Warn("La señal no existe", slog.Int("senal", senal))
+		return nil
+	}
+
+	// CONT reanuda al proceso aunque tenga manejador, si no el manejador nunca se ejecutaria
+	if senal == SenalContinuar {
+		if err := reanudarProceso(pid); err != nil && !errors.Is(err, errProcesoNoSuspendido) {
+			return err
+		}
+	}
+
+	mutexSenales.Lock()
+	manejador, manejada := manejadoresSenal[pid][senal]
+	if manejada {
+		senalesPendientes[pid] = append(senalesPendientes[pid], senal)
+	}
+	mutexSenales.Unlock()
+
+	if manejada {
+		log.Printf("## (<PID %d>) Recibe la señal %d - Queda pendiente para el manejador en el PC %d ##", pid, senal, manejador)
+		// Los hilos que estan ejecutando vuelven a READY para atenderla en el proximo despacho. Se
+		// marca el desalojo para que el algoritmo no tome la rafaga cortada como completa.
+		for _, cpu := range getCpusOcupadas() {
+			if cpu.hilo.Pid == pid && marcarDesalojo(getCpu(cpu.Id), cpu.despacho, false) {
+				go enviarInterrupcion(cpu.hilo.Pid, cpu.hilo.Tid, "Senal")
+			}
+		}
+		return nil
+	}
+
+	log.Printf("## (<PID %d>) Recibe la señal %d - Accion por defecto: %s ##", pid, senal, accion)
+
+	switch accion {
+	case AccionTerminar:
+		return matarProceso(pid, EstadoSalidaSenal)
+	case AccionDetener:
+		return suspenderProceso(pid)
+	}
+	return nil
+}
+
+// tomarSenalPendiente devuelve la proxima señal a entregar a un hilo del proceso
+func tomarSenalPendiente(pid int) (SenalEntregada, bool) {
+	mutexSenales.Lock()
+	defer mutexSenales.Unlock()
+
+	pendientes := senalesPendientes[pid]
+	if len(pendientes) == 0 {
+		return SenalEntregada{}, false
+	}
+	senal := pendientes[0]
+	senalesPendientes[pid] = pendientes[1:]
+	return SenalEntregada{Senal: senal, Manejador: manejadoresSenal[pid][senal]}, true
+}
+
+func olvidarSenales(pid int) {
+	mutexSenales.Lock()
+	delete(manejadoresSenal, pid)
+	delete(senalesPendientes, pid)
+	mutexSenales.Unlock()
+}
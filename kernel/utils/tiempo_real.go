@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+/*---------------------- PLANIFICACION DE TIEMPO REAL (EDF) ----------------------*/
+
+// Los procesos de tiempo real se planifican por EDF antes que cualquier hilo
+// normal. Cada vez que un hilo del proceso pasa a READY se libera un trabajo
+// cuyo deadline es el momento de liberacion mas el deadline relativo. Como el
+// periodo es el tiempo minimo entre liberaciones, un trabajo no se considera
+// liberado antes de que pase un periodo desde el anterior. El trabajo termina
+// cuando el hilo deja la CPU sin haber sido desalojado.
+
+type ParametrosTiempoReal struct {
+	Periodo  int `json:"periodo"`  // ms
+	Deadline int `json:"deadline"` // ms, relativo a la liberacion de cada trabajo
+	Computo  int `json:"computo"`  // ms de CPU que necesita cada trabajo
+}
+
+type procesoTiempoReal struct {
+	parametros       ParametrosTiempoReal
+	ultimaLiberacion time.Time
+	trabajos         map[int]*trabajoTiempoReal // trabajo pendiente de cada hilo
+}
+
+type trabajoTiempoReal struct {
+	id       int
+	deadline time.Time
+}
+
+type PlanificadorTiempoReal struct {
+	mutex     sync.Mutex
+	procesos  map[int]*procesoTiempoReal
+	trabajos  int // contador para identificar los trabajos
+	utilizado float64
+}
+
+var tiempoReal = &PlanificadorTiempoReal{procesos: make(map[int]*procesoTiempoReal)}
+
+func iniciarProcesoTiempoReal(path string, size int, prioridad int, parametros ParametrosTiempoReal, padre int) (int, error) {
+	// La utilizacion se reserva antes de crear el PCB, asi un rechazo no consume un PID
+	parametros, err := tiempoReal.reservar(parametros)
+	if err != nil {
+		return 0, err
+	}
+	pcb := createPCB()
+	tiempoReal.registrar(pcb.Pid, parametros)
+	registrarProceso(pcb.Pid, padre)
+
+	var proceso Proceso = Proceso{pcb, size, path, prioridad}
+	encolarProcesoSinIniciar(proceso)
+	admitirProcesos()
+	return pcb.Pid, nil
+}
+
+// reservar suma la utilizacion del proceso si la utilizacion total no supera 1.
+// Devuelve los parametros con el deadline por defecto completado.
+func (t *PlanificadorTiempoReal) reservar(parametros ParametrosTiempoReal) (ParametrosTiempoReal, error) {
+	if parametros.Deadline == 0 {
+		parametros.Deadline = parametros.Periodo
+	}
+	if parametros.Periodo <= 0 || parametros.Deadline <= 0 || parametros.Computo <= 0 {
+		return parametros, fmt.Errorf("parametros de tiempo real invalidos: periodo %d - deadline %d - computo %d", parametros.Periodo, parametros.Deadline, parametros.Computo)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	utilizacion := float64(parametros.Computo) / float64(parametros.Periodo)
+	if t.utilizado+utilizacion > 1 {
+		return parametros, fmt.Errorf("utilizacion total %.2f supera 1", t.utilizado+utilizacion)
+	}
+	t.utilizado += utilizacion
+	return parametros, nil
+}
+
+// registrar da de alta como de tiempo real al proceso cuya utilizacion ya se reservo
+func (t *PlanificadorTiempoReal) registrar(pid int, parametros ParametrosTiempoReal) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.procesos[pid] = &procesoTiempoReal{
+		parametros: parametros,
+		trabajos:   make(map[int]*trabajoTiempoReal),
+	}
+	log.Printf("## (<PID %d>) Proceso de tiempo real - Periodo: %d - Deadline: %d - Computo: %d - Utilizacion total: %.2f ##", pid, parametros.Periodo, parametros.Deadline, parametros.Computo, t.utilizado)
+}
+
+func (t *PlanificadorTiempoReal) quitarProceso(pid int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	proceso, ok := t.procesos[pid]
+	if !ok {
+		return
+	}
+	t.utilizado -= float64(proceso.parametros.Computo) / float64(proceso.parametros.Periodo)
+	delete(t.procesos, pid)
+}
+
+// liberar crea el trabajo del hilo que paso a READY, salvo que tenga uno
+// pendiente porque vuelve de un desalojo
+func (t *PlanificadorTiempoReal) liberar(hilo TCB) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	proceso, ok := t.procesos[hilo.Pid]
+	if !ok {
+		return
+	}
+	if _, pendiente := proceso.trabajos[hilo.Tid]; pendiente {
+		return
+	}
+
+	liberacion := time.Now()
+	minimaLiberacion := proceso.ultimaLiberacion.Add(time.Duration(proceso.parametros.Periodo) * time.Millisecond)
+	if liberacion.Before(minimaLiberacion) {
+		liberacion = minimaLiberacion
+	}
+	proceso.ultimaLiberacion = liberacion
+
+	t.trabajos++
+	trabajo := &trabajoTiempoReal{
+		id:       t.trabajos,
+		deadline: liberacion.Add(time.Duration(proceso.parametros.Deadline) * time.Millisecond),
+	}
+	proceso.trabajos[hilo.Tid] = trabajo
+
+	time.AfterFunc(time.Until(trabajo.deadline), func() {
+		t.verificarDeadline(hilo, trabajo.id)
+	})
+}
+
+func (t *PlanificadorTiempoReal) verificarDeadline(hilo TCB, id int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	proceso, ok := t.procesos[hilo.Pid]
+	if !ok {
+		return
+	}
+	if trabajo, pendiente := proceso.trabajos[hilo.Tid]; pendiente && trabajo.id == id {
+		log.Printf("## (<PID %d>:<TID %d>) - Deadline perdido ##", hilo.Pid, hilo.Tid)
+	}
+}
+
+// deadline devuelve el deadline del trabajo pendiente del hilo. Requiere el mutex tomado.
+func (t *PlanificadorTiempoReal) deadline(hilo TCB) (time.Time, bool) {
+	proceso, ok := t.procesos[hilo.Pid]
+	if !ok {
+		return time.Time{}, false
+	}
+	trabajo, pendiente := proceso.trabajos[hilo.Tid]
+	if !pendiente {
+		return time.Time{}, false
+	}
+	return trabajo.deadline, true
+}
+
+// elegir devuelve el hilo de tiempo real en READY con el deadline mas proximo y
+// el resto de los hilos, que quedan para el algoritmo de corto plazo
+func (t *PlanificadorTiempoReal) elegir(colaReady []TCB) (TCB, bool, []TCB) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var elegido TCB
+	var deadlineElegido time.Time
+	hayElegido := false
+	var normales []TCB
+	for _, hilo := range colaReady {
+		deadline, ok := t.deadline(hilo)
+		if !ok {
+			normales = append(normales, hilo)
+			continue
+		}
+		if !hayElegido || deadline.Before(deadlineElegido) {
+			elegido = hilo
+			deadlineElegido = deadline
+			hayElegido = true
+		}
+	}
+	return elegido, hayElegido, normales
+}
+
+func (t *PlanificadorTiempoReal) tieneTrabajo(hilo TCB) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	_, ok := t.deadline(hilo)
+	return ok
+}
+
+// desalojar decide el desalojo cuando alguno de los dos hilos es de tiempo real
+func (t *PlanificadorTiempoReal) desalojar(candidato TCB, ejecutando TCB) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	deadlineCandidato, candidatoTiempoReal := t.deadline(candidato)
+	deadlineEjecutando, ejecutandoTiempoReal := t.deadline(ejecutando)
+
+	if !candidatoTiempoReal {
+		return false
+	}
+	if !ejecutandoTiempoReal {
+		return true
+	}
+	return deadlineCandidato.Before(deadlineEjecutando)
+}
+
+// finDeRafaga da por terminado el trabajo si el hilo dejo la CPU sin ser desalojado
+func (t *PlanificadorTiempoReal) finDeRafaga(hilo TCB, rafaga Rafaga) {
+	if rafaga.Desalojado {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	proceso, ok := t.procesos[hilo.Pid]
+	if !ok {
+		return
+	}
+	if trabajo, pendiente := proceso.trabajos[hilo.Tid]; pendiente {
+		if time.Now().After(trabajo.deadline) {
+			log.Printf("## (<PID %d>:<TID %d>) - Trabajo de tiempo real terminado despues del deadline ##", hilo.Pid, hilo.Tid)
+		}
+		delete(proceso.trabajos, hilo.Tid)
+	}
+}
+
+func (t *PlanificadorTiempoReal) olvidar(hilo TCB) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if proceso, ok := t.procesos[hilo.Pid]; ok {
+		delete(proceso.trabajos, hilo.Tid)
+	}
+}
@@ -23,16 +23,17 @@ type Interrupcion struct {
 }
 
 type PCB struct {
-	Pid   int
-	Tid   []int
-	Mutex []Mutex
+	Pid         int
+	Tid         []int
+	Mutex       []Mutex
+	Semaforos   []Semaforo
+	Condiciones []Condicion
 }
 
 type TCB struct {
-	Pid             int
-	Tid             int
-	Prioridad       int
-	HilosBloqueados []int
+	Pid       int
+	Tid       int
+	Prioridad int
 }
 
 type Mutex struct {
@@ -43,15 +44,17 @@ type Mutex struct {
 }
 
 type CambioHilos struct {
-	Pid       int `json:"pid"`
-	TidActual int `json:"tidActual"`
-	TidCambio int `json:"tidAEjecutar"`
+	Pid       int    `json:"pid"`
+	TidActual int    `json:"tidActual"`
+	TidCambio int    `json:"tidAEjecutar"`
+	Registro  string `json:"registro,omitempty"` // THREAD_JOIN: registro donde se deja el codigo de salida
 }
 
 type IOsyscall struct {
-	TiempoIO int `json:"tiempoIO"`
-	Pid      int `json:"pid"`
-	Tid      int `json:"tid"`
+	TiempoIO    int    `json:"tiempoIO"`
+	Pid         int    `json:"pid"`
+	Tid         int    `json:"tid"`
+	Dispositivo string `json:"dispositivo,omitempty"`
 }
 
 // Request
@@ -66,28 +69,44 @@ type TCBRequestMemory struct {
 	Path string `json:"path"`
 }
 
+type RegistroRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Registro string `json:"registro"`
+	Valor    uint32 `json:"valor"`
+}
+
 type TCBRequest struct {
 	Pid int `json:"pid"`
 	Tid int `json:"tid"`
 }
 
+type DespachoRequest struct {
+	Pid   int             `json:"pid"`
+	Tid   int             `json:"tid"`
+	Senal *SenalEntregada `json:"senal,omitempty"`
+}
+
 type PCBRequest struct {
 	Pid int `json:"pid"`
 }
 
 type MutexRequest struct {
-	Pid   int    `json:"pid"`
-	Tid   int    `json:"tid"`
-	Mutex string `json:"mutex"`
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Mutex    string `json:"mutex"`
+	Registro string `json:"registro,omitempty"` // registro donde se deja el resultado de TRYLOCK y TIMEDLOCK
+	Tiempo   int    `json:"tiempo,omitempty"`   // ms de espera maxima de TIMEDLOCK
 }
 
 // Response
 type IniciarProcesoResponse struct {
-	Path      string `json:"path"`
-	Size      int    `json:"size"`
-	Prioridad int    `json:"prioridad"`
-	PidActual int    `json:"pidActual"`
-	TidActual int    `json:"tidActual"`
+	Path       string                `json:"path"`
+	Size       int                   `json:"size"`
+	Prioridad  int                   `json:"prioridad"`
+	PidActual  int                   `json:"pidActual"`
+	TidActual  int                   `json:"tidActual"`
+	TiempoReal *ParametrosTiempoReal `json:"tiempoReal,omitempty"`
 }
 
 type CrearHiloResponse struct {
@@ -132,8 +151,6 @@ var mutexColaExecHilo sync.Mutex
 var mutexColaBlockHilo sync.Mutex
 var mutexColaExitHilo sync.Mutex
 
-var mutexEsperarCompactacion sync.Mutex
-
 /*-------------------- VAR GLOBALES --------------------*/
 
 var (
@@ -147,7 +164,6 @@ var ConfigKernel *globals.Config
 /*---------------------- CANALES ----------------------*/
 
 //var esperarFinProceso bool = true
-var esperarFinCompactacion bool = true
 
 //VER CANAL esperarFinProceso QUE LO USAMOS PARA SABER CUANDO FINALIZA UN PROCESO Y ASI PODER INICIALIZAR OTRO PERO NOS ESTA SIENDO BLOQUEANTE
 
@@ -203,17 +219,38 @@ func init() {
 			slog.SetLogLoggerLevel(slog.LevelDebug)
 		}
 
-		procesoInicial(ConfigKernel.ArchivoInicial, ConfigKernel.SizeInicial)
+		algoritmo, err := crearScheduler(ConfigKernel)
+		if err != nil {
+			log.Fatalf("Algoritmo de planificacion no valido")
+		}
+		planificador = nuevoPlanificador(algoritmo, ConfigKernel.IntervaloEnvejecimiento)
+		iniciarCpus(ConfigKernel)
+
+		politicaAdmision, err = crearPoliticaAdmision(ConfigKernel.PoliticaAdmision)
+		if err != nil {
+			log.Fatalf("Politica de admision no valida")
+		}
+
+		politicaDeadlock, err = crearPoliticaDeadlock(ConfigKernel.PoliticaDeadlock)
+		if err != nil {
+			log.Fatalf("Politica de deadlock no valida")
+		}
 
-		if ConfigKernel.AlgoritmoPlanificacion == "FIFO" {
-			go ejecutarHilosFIFO()
-		} else if ConfigKernel.AlgoritmoPlanificacion == "PRIORIDADES" {
-			go ejecutarHilosPrioridades()
-		} else if ConfigKernel.AlgoritmoPlanificacion == "CMN" {
-			go ejecutarHilosColasMultinivel(ConfigKernel.Quantum)
+		dispositivosIO, err = crearDispositivosIO(ConfigKernel.DispositivosIO)
+		if err != nil {
+			log.Fatalf("Dispositivos de IO no validos: %v", err)
+		}
+		iniciarDispositivosIO()
+
+		if archivo, restaurar := archivoRestauracion(); restaurar {
+			if err := restaurarCheckpoint(archivo); err != nil {
+				log.Fatalf("No se pudo restaurar el checkpoint %s: %v", archivo, err)
+			}
 		} else {
-			log.Fatalf("Algoritmo de planificacion no valido")
+			procesoInicial(ConfigKernel.ArchivoInicial, ConfigKernel.SizeInicial)
 		}
+
+		go planificador.ejecutar()
 	} else {
 		log.Fatalf("Configuracion no inicializada, segui participando...")
 	}
@@ -224,22 +261,26 @@ func init() {
 func procesoInicial(path string, size int) {
 
 	pcb := createPCB()
+	pidInicial = pcb.Pid
+	registrarProceso(pcb.Pid, sinPadre)
 	//encolarProcesoNew(pcb)
 	var proceso Proceso = Proceso{pcb, size, path, 0}
-	mutexProcesosSinIniciar.Lock()
-	colaProcesosSinIniciar = append(colaProcesosSinIniciar, proceso)
-	mutexProcesosSinIniciar.Unlock()
-	inicializarProceso(path, size, 0, pcb)
+	encolarProcesoSinIniciar(proceso)
+	admitirProcesos()
 
 }
 
 func createPCB() PCB {
 	nextPid++
+	nextTid = append(nextTid, 0) // nextTid se indexa por pid - 1, aunque el proceso se admita despues que otros
+	registrarInicioProceso(nextPid - 1)
 
 	return PCB{
-		Pid:   nextPid - 1,
-		Tid:   []int{},
-		Mutex: []Mutex{},
+		Pid:         nextPid - 1,
+		Tid:         []int{},
+		Mutex:       []Mutex{},
+		Semaforos:   []Semaforo{},
+		Condiciones: []Condicion{},
 	}
 }
 
@@ -269,8 +310,13 @@ func CrearProceso(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <PROCESS_CREATE> ##", pidActual, tidActual)
 
-	
-	iniciarProceso(path, size, prioridad)
+	if proceso.TiempoReal != nil {
+		if _, err := iniciarProcesoTiempoReal(path, size, prioridad, *proceso.TiempoReal, pidActual); err != nil {
+			log.Printf("## (<PID:%d>:<TID:%d>) - Se rechaza el proceso de tiempo real: %s ##", pidActual, tidActual, err.Error())
+		}
+	} else {
+		iniciarProceso(path, size, prioridad, pidActual)
+	}
 
 	tcbActual := getTCB(pidActual, tidActual)
 
@@ -279,61 +325,16 @@ func CrearProceso(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func iniciarProceso(path string, size int, prioridad int) {
+func iniciarProceso(path string, size int, prioridad int, padre int) int {
 
 	pcb := createPCB()
+	registrarProceso(pcb.Pid, padre)
 	//encolarProcesoNew(pcb)
 	var proceso Proceso = Proceso{pcb, size, path, prioridad}
-	mutexProcesosSinIniciar.Lock()
-	colaProcesosSinIniciar = append(colaProcesosSinIniciar, proceso)
-	mutexProcesosSinIniciar.Unlock()
-	inicializarProceso(path, size, prioridad, pcb)
-	//go inicializarProceso(path, size, prioridad, pcb)
+	encolarProcesoSinIniciar(proceso)
+	admitirProcesos()
 
-}
-
-
-func inicializarProceso(path string, size int, prioridad int, pcb PCB){
-
-	log.Printf(" ## (<PID>:%d) Se crea el proceso - Estado: NEW ##", pcb.Pid)
-
-	
-	if esElPrimerProcesoSinIniciar(pcb) {
-		
-		estadoMemoria := consultaEspacioAMemoria(size, pcb)
-	
-		if estadoMemoria == HayEspacio{
-			nextTid = append(nextTid, 0)
-			tcb := createTCB(pcb.Pid, prioridad) 
-			pcb.Tid = append(pcb.Tid, tcb.Tid)   
-
-			enviarTCBMemoria(tcb, path)
-
-			colaProcesosSinIniciar = colaProcesosSinIniciar[1:]
-
-			//quitarProcesoNew(pcb)
-			encolarProcesoInicializado(pcb)
-			encolarReady(tcb)
-
-		}else if estadoMemoria == Compactar{
-
-			mutexEsperarCompactacion.Lock()
-			esperarFinCompactacion = false
-			mutexEsperarCompactacion.Unlock()
-
-			compactar()
-			inicializarProceso(path, size, prioridad, pcb)
-
-			mutexEsperarCompactacion.Lock()
-			esperarFinCompactacion = true
-			mutexEsperarCompactacion.Unlock()
-
-		}else if estadoMemoria == NoHayEspacio{
-			log.Printf("## (<PID: %d >) NO HAY PARTICIONES DISPONIBLES PARA SU TAMANIO", pcb.Pid)
-		}else{
-			slog.Error("Error en el estado de la memoria")
-		}
-	}
+	return pcb.Pid
 }
 
 
@@ -391,10 +392,6 @@ const (
 	return colaNewproceso[0].Pid == pcb.Pid
 }*/
 
-func esElPrimerProcesoSinIniciar(pcb PCB) bool {
-	return colaProcesosSinIniciar[0].PCB.Pid == pcb.Pid
-}
-
 func compactar(){
 
 	puerto := ConfigKernel.PuertoMemoria
@@ -429,7 +426,7 @@ func FinalizarProceso(w http.ResponseWriter, r *http.Request) {
 	log.Printf("## (<PID:%d>:<TID:%d>) - Solicitó syscall: <PROCESS_EXIT> ##", pid, tid)
 
 	if tid == 0 {
-		err = exitProcess(pid)
+		err = exitProcess(pid, EstadoSalidaNormal)
 	} else {
 		slog.Warn("El hilo no es el principal, no se puede ejecutar esta instruccion")
 	}
@@ -440,30 +437,36 @@ func FinalizarProceso(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func exitProcess(pid int) error { //Consulta de nico: teoricamente si encuentra un hilo en block no deberia estar en ninguna otra, no?
+func exitProcess(pid int, estado uint32) error { //Consulta de nico: teoricamente si encuentra un hilo en block no deberia estar en ninguna otra, no?
 
 	
-	for _, tcb := range colaReadyHilo {
+	for _, tcb := range append([]TCB(nil), colaReadyHilo...) {
 		if tcb.Pid == pid {
-			exitHilo(pid, tcb.Tid)
+			exitHilo(pid, tcb.Tid, CodigoSalidaCancelado)
 		}
 	} // LO PUSE ASI PORQUE NO SOLO HABIA QUE MOVER A EXIT SINO TAMBIEN AVISAR QUE FINALIZA (es decir lo que hace la funcion exit proceses)
 
-	for _, tcb := range colaExecHilo {
+	for _, tcb := range append([]TCB(nil), colaExecHilo...) {
 		if tcb.Pid == pid {
-			exitHilo(pid, tcb.Tid)
+			exitHilo(pid, tcb.Tid, CodigoSalidaCancelado)
 		}
 	}
 
-	for _, tcb := range colaBlockHilo {
+	for _, tcb := range append([]TCB(nil), colaBlockHilo...) {
 		if tcb.Pid == pid {
-			exitHilo(pid, tcb.Tid)
+			exitHilo(pid, tcb.Tid, CodigoSalidaCancelado)
 		}
 	}
 
 	pcb, _ := getPCB(pid)
 	quitarProcesoInicializado(pcb)
 	encolarProcesoExit(pcb)
+	tiempoReal.quitarProceso(pid)
+	olvidarHilosFinalizados(pid)
+	olvidarSenales(pid)
+	liberarColasDeMensajes(pid)
+	finalizarEnArbol(pid, estado)
+	registrarFinProceso(pid)
 
 
 	resp := enviarProcesoFinalizadoAMemoria(pcb)
@@ -471,10 +474,8 @@ func exitProcess(pid int) error { //Consulta de nico: teoricamente si encuentra
 	if resp == nil {
 		// Notificar a traves del canal
 		//esperarFinProceso = true
-		if len(colaProcesosSinIniciar) > 0 {
-			proceso := colaProcesosSinIniciar[0]
-			inicializarProceso(proceso.Path, proceso.Size, proceso.Prioridad, proceso.PCB)
-		}
+		admitirProcesos()
+		imprimirResumenSiVacio()
 
 	} else {
 		slog.Error("Error al enviar el proceso finalizado a memoria")
@@ -596,10 +597,9 @@ func createTCB(pid int, prioridad int) TCB {
 	nextTid[pid - 1]++
 
 	return TCB{
-		Pid:             pid,
-		Tid:             nextTid[pid - 1] - 1,
-		Prioridad:       prioridad,
-		HilosBloqueados: []int{},
+		Pid:       pid,
+		Tid:       nextTid[pid - 1] - 1,
+		Prioridad: prioridad,
 	}
 }
 
@@ -674,7 +674,7 @@ func iniciarHilo(pid int, path string, prioridad int) error {
 }
 
 func FinalizarHilo(w http.ResponseWriter, r *http.Request) { //pedir a cpu que nos pase PID Y TID del hilo
-	var hilo TCBRequest
+	var hilo FinHiloRequest
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&hilo)
 
@@ -688,13 +688,15 @@ func FinalizarHilo(w http.ResponseWriter, r *http.Request) { //pedir a cpu que n
 
 	log.Printf("## (<PID:%d>:<TID:%d>) - Solicito syscall: <THREAD_EXIT> ##", pid, tid)
 
-	err = exitHilo(pid, tid)
+	err = exitHilo(pid, tid, hilo.Codigo)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	admitirProcesos()
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -712,13 +714,15 @@ func CancelarHilo(w http.ResponseWriter, r *http.Request) {
 	tidEliminar := hilosCancel.TidCambio
 	log.Printf("## (<PID:%d>:<TID:%d>) - Solicito syscall: <THREAD_CANCEL> ##", pid, tid)
 
-	err = exitHilo(pid, tidEliminar)
+	err = exitHilo(pid, tidEliminar, CodigoSalidaCancelado)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	admitirProcesos()
+
 	tcbActual := getTCB(pid, tid)
 
 	enviarTCBCpu(tcbActual)
@@ -744,10 +748,20 @@ func EntrarHilo(w http.ResponseWriter, r *http.Request) { //debe ser del mismo p
 	tcbActual := getTCB(pid, tidActual)
 	log.Printf("## (<PID:%d>:<TID:%d>) - Solicito syscall: <THREAD_JOIN> ##", pid, tidActual)
 
-	if(isInReady(tcbAEjecutar)){
-	err = joinHilo(pid, tidActual, tidAEjecutar)
-	}else{
-		log.Printf("## (<PID:%d>:<TID:%d>) - No se puede hacer join con un hilo que no existe ##", pid, tidActual)
+	// Se puede esperar a cualquier otro hilo del proceso que no haya finalizado.
+	// Si ya finalizo se devuelve su codigo de salida sin bloquear.
+	if tidAEjecutar != tidActual && (isInReady(tcbAEjecutar) || isInExec(tcbAEjecutar) || isInBlock(tcbAEjecutar)) {
+		esperarCodigoSalida(tcbActual, hilosJoin.Registro)
+		err = joinHilo(pid, tidActual, tidAEjecutar)
+	} else {
+		codigo, finalizado := codigoSalida(pid, tidAEjecutar)
+		if !finalizado || tidAEjecutar == tidActual {
+			log.Printf("## (<PID:%d>:<TID:%d>) - No se puede hacer join con un hilo que no existe ##", pid, tidActual)
+			codigo = CodigoJoinInvalido
+		}
+		if hilosJoin.Registro != "" {
+			escribirRegistro(tcbActual, hilosJoin.Registro, codigo)
+		}
 		enviarTCBCpu(tcbActual)
 	}
 	if err != nil {
@@ -759,10 +773,18 @@ func EntrarHilo(w http.ResponseWriter, r *http.Request) { //debe ser del mismo p
 
 }
 
-func exitHilo(pid int, tid int) error {
+func exitHilo(pid int, tid int, codigo uint32) error {
 	hilo := getTCB(pid, tid)
 	pcb, _ := getPCB(pid)
 	pcb.Tid = removeTid(pcb.Tid, tid)
+	quitarDeColasDeMutex(pcb, hilo)
+	quitarDeColasDeSemaforos(pcb, hilo)
+	quitarDeColasDeCondiciones(pcb, hilo)
+	cancelarEsperaMutex(hilo)
+	quitarDeColasIO(hilo)
+	cancelarEsperaProceso(hilo)
+	quitarDeColasDeMensajes(hilo)
+	cancelarSueno(hilo)
 	actualizarPCB(pcb)
 
 	switch {
@@ -775,14 +797,18 @@ func exitHilo(pid int, tid int) error {
 	}
 
 	encolarExit(hilo)
+	registrarFinHilo(hilo, codigo)
+	quitarDeEsperasJoin(hilo)
 
-	for _, tidBloqueado := range hilo.HilosBloqueados {
-		desbloquearHilosJoin(tidBloqueado, pid)
+	for _, tidBloqueado := range tomarEsperasJoin(hilo) {
+		desbloquearHilosJoin(tidBloqueado, pid, codigo)
 	}
-	if tieneMutexAsignado(pcb, hilo) {
+	for tieneMutexAsignado(pcb, hilo) {
 		mutexUsando := getMutexUsando(pcb, hilo)
 		unlockMutex(pcb, hilo, mutexUsando.Nombre)
+		pcb, _ = getPCB(pid)
 	}
+	recalcularHerencia()
 
 	err := enviarHiloFinalizadoAMemoria(hilo)
 	if err != nil {
@@ -808,6 +834,11 @@ func isInBlock(hilo TCB) bool {
 	return err == nil
 }
 
+func isInExit(hilo TCB) bool {
+	_, err := buscarPorPidYTid(colaExitHilo, hilo.Pid, hilo.Tid)
+	return err == nil
+}
+
 func tieneMutexAsignado(pcb PCB, hilo TCB) bool {
 	for _, mutex := range pcb.Mutex {
 		if mutex.HiloUsando == hilo.Tid {
@@ -828,117 +859,46 @@ func getMutexUsando(pcb PCB, hilo TCB) Mutex {
 
 func joinHilo(pid int, tidActual int, tidAEjecutar int) error {
 	tcbActual := getTCB(pid, tidActual)
-	tcbAEjecutar := getTCB(pid, tidAEjecutar)
-
-	tcbAEjecutar.HilosBloqueados = append(tcbAEjecutar.HilosBloqueados, tidActual)
-	actualizarTCB(tcbAEjecutar)
 
+	// El hilo pasa a BLOCK antes de anotarse en el join, asi el exit del hilo
+	// esperado siempre lo encuentra bloqueado
 	quitarExec(tcbActual)
 	encolarBlock(tcbActual, "PTHREAD_JOIN")
 
-	return nil
-}
-
-func actualizarTCB(tcb TCB) {
-	for i, hilo := range colaReadyHilo {
-		if hilo.Pid == tcb.Pid && hilo.Tid == tcb.Tid {
-			colaReadyHilo[i] = tcb
-		}
+	if codigo, finalizado := agregarEsperaJoin(pid, tidAEjecutar, tidActual); finalizado {
+		desbloquearHilosJoin(tidActual, pid, codigo)
+		return nil
 	}
-}
-
-/*---------- FUNCIONES HILOS ALGORITMOS PLANIFICACION ----------*/
-//FIFO
-func ejecutarHilosFIFO() {
-	var Hilo TCB
-	for {
-		if len(colaReadyHilo) > 0 && len(colaExecHilo) == 0 && esperarFinCompactacion {
-			Hilo = colaReadyHilo[0]
-			ejecutarInstruccion(Hilo)
-		}
-	}
-}
+	detectarDeadlock(tcbActual)
 
-func ejecutarInstruccion(Hilo TCB) {
-	if esperarFinCompactacion {
-		quitarReady(Hilo)
-		encolarExec(Hilo)
-		enviarTCBCpu(Hilo)
-	}
-}
-
-// PRIORIDADES
-func ejecutarHilosPrioridades() {
-	var Hilo TCB
-	for {
-		if len(colaReadyHilo) > 0 && len(colaExecHilo) == 0 && esperarFinCompactacion{
-			HiloAEjecutar := obtenerHiloMayorPrioridad()
-			ejecutarInstruccion(HiloAEjecutar)
-
-		} else if len(colaReadyHilo) > 0 && len(colaExecHilo) >= 1 && esperarFinCompactacion{
-			
-			if Hilo.Prioridad < colaExecHilo[0].Prioridad {
-				enviarInterrupcion(colaExecHilo[0].Pid, colaExecHilo[0].Tid, "Prioridades")
-			}
-		}
-	}
+	return nil
 }
 
+/*---------- FUNCIONES HILOS ENVIO DE TCB ----------*/
 
-func obtenerHiloMayorPrioridad() TCB {
-
-	mutexColaReadyHilo.Lock()
-
-	hiloMayorPrioridad := colaReadyHilo[0]
-	for _, hilo := range colaReadyHilo {
-		if hilo.Prioridad < hiloMayorPrioridad.Prioridad {
-			hiloMayorPrioridad = hilo
-		}
-	}
-
-	mutexColaReadyHilo.Unlock()
-
-	return hiloMayorPrioridad
-}
-
-// MULTICOLAS
-func ejecutarHilosColasMultinivel(quantum int) {
-	var Hilo TCB
-	for {
-		if len(colaReadyHilo) > 0 && len(colaExecHilo) == 0 && esperarFinCompactacion{
-			Hilo = obtenerHiloMayorPrioridad()
-			go comenzarQuantum(Hilo, quantum)
-			ejecutarInstruccion(Hilo)
-		} else if len(colaReadyHilo) > 0 && len(colaExecHilo) >= 1 && esperarFinCompactacion{
-			
-			if Hilo.Prioridad < colaExecHilo[0].Prioridad {
-				enviarInterrupcion(colaExecHilo[0].Pid, colaExecHilo[0].Tid, "Prioridades")
-			}
-		}
+// enviarTCBCpu le devuelve el hilo a la CPU que lo esta ejecutando, para que siga despues de una syscall
+func enviarTCBCpu(tcb TCB) error {
+	cpu, err := getCpuDelHilo(tcb.Pid, tcb.Tid)
+	if err != nil {
+		slog.Error("Error enviando TCB", slog.Any("error", err))
+		return err
 	}
+	return enviarTCBACpu(cpu, tcb)
 }
 
-func comenzarQuantum(Hilo TCB, quantum int) {
-
-	 time.Sleep(time.Duration(quantum) * time.Millisecond)
-
-	 if len(colaExecHilo) > 0 {
-		if Hilo.Tid == colaExecHilo[0].Tid && Hilo.Pid == colaExecHilo[0].Pid {
-			enviarInterrupcion(Hilo.Pid, Hilo.Tid, "Quantum")
-		}
-	 }
-			
+func enviarTCBACpu(cpu *Cpu, tcb TCB) error {
+	return enviarDespachoACpu(cpu, tcb, nil)
 }
 
-/*---------- FUNCIONES HILOS ENVIO DE TCB ----------*/
-
-func enviarTCBCpu(tcb TCB) error {
-	cpuRequest := TCBRequest{}
+// enviarDespachoACpu manda el hilo a la CPU, con la señal que tiene que atender antes de seguir si hay una
+func enviarDespachoACpu(cpu *Cpu, tcb TCB, senal *SenalEntregada) error {
+	cpuRequest := DespachoRequest{}
 	cpuRequest.Pid = tcb.Pid
 	cpuRequest.Tid = tcb.Tid
+	cpuRequest.Senal = senal
 
-	puerto := ConfigKernel.PuertoCpu
-	ip := ConfigKernel.IpCpu
+	puerto := cpu.Puerto
+	ip := cpu.Ip
 
 	body, err := json.Marshal(&cpuRequest)
 
@@ -1026,10 +986,46 @@ func enviarHiloFinalizadoAMemoria(hilo TCB) error {
 	return nil
 }
 
+// escribirRegistro deja el resultado de una syscall en un registro del hilo, que la CPU lee al recibirlo
+func escribirRegistro(hilo TCB, registro string, valor uint32) error {
+
+	memoryRequest := RegistroRequest{}
+	memoryRequest.Pid = hilo.Pid
+	memoryRequest.Tid = hilo.Tid
+	memoryRequest.Registro = registro
+	memoryRequest.Valor = valor
+
+	puerto := ConfigKernel.PuertoMemoria
+	ip := ConfigKernel.IpMemoria
+
+	body, err := json.Marshal(&memoryRequest)
+
+	if err != nil {
+		slog.Error("error codificando" + err.Error())
+		return err
+	}
+
+	url := fmt.Sprintf("http://%s:%d/escribirRegistro", ip, puerto)
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+
+	if err != nil {
+		slog.Error("Error escribiendo registro en memoria", slog.String("registro", registro), slog.Any("error", err))
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		slog.Error("Error en la respuesta del modulo de Memoria al escribir registro", slog.Int("status_code", resp.StatusCode))
+		return fmt.Errorf("error escribiendo el registro %s del hilo %d:%d", registro, hilo.Pid, hilo.Tid)
+	}
+
+	return nil
+}
+
 /*---------- FUNCIONES DE ESTADOS DE HILOS ----------*/
-func desbloquearHilosJoin(tid int, pid int) {
+func desbloquearHilosJoin(tid int, pid int, codigo uint32) {
 	for _, hilo := range colaBlockHilo {
 		if hilo.Tid == tid && hilo.Pid == pid {
+			entregarCodigoSalida(hilo, codigo)
 			quitarBlock(hilo)
 
 			encolarReady(hilo)
@@ -1041,79 +1037,28 @@ func desbloquearHilosJoin(tid int, pid int) {
 
 func encolarReady(tcb TCB) {
 
+	if retenerSiSuspendido(tcb) {
+		return
+	}
+
+	registrarIngresoReady(tcb)
+	tiempoReal.liberar(tcb)
+	cambiarEstadoMetricas(tcb, EstadoReady)
+
 	mutexColaReadyHilo.Lock()
 	colaReadyHilo = append(colaReadyHilo, tcb)
 	mutexColaReadyHilo.Unlock()
 
 	log.Printf("## (<PID %d>:<TID %d>) Se encola el Hilo - Estado: READY", tcb.Pid, tcb.Tid)
 
-	//go verificarReplanificar(tcb)
-}
-
-
-/*func verificarReplanificar(tcb TCB){
-
-	switch ConfigKernel.AlgoritmoPlanificacion {
-	case "FIFO":
-		if len(colaExecHilo) == 0 && len(colaReadyHilo) > 0 && esperarFinCompactacion{
-			Hilo := colaReadyHilo[0]
-			ejecutarInstruccion(Hilo)
-			break
-		}
-	case "PRIORIDADES":
-		if len(colaExecHilo) == 0 && len(colaReadyHilo) > 0 && esperarFinCompactacion{
-			Hilo := obtenerHiloMayorPrioridad()
-			ejecutarInstruccion(Hilo)
-			break
-		} else if len(colaExecHilo) > 0 && tcb.Prioridad < colaExecHilo[0].Prioridad && esperarFinCompactacion{		 
-			enviarInterrupcion(colaExecHilo[0].Pid, colaExecHilo[0].Tid, "Prioridades")
-			break
-		}
-	case "CMN":
-		if len(colaExecHilo) == 0 && len(colaReadyHilo) > 0 && esperarFinCompactacion{
-			log.Printf("## Se replanifica el hilo despues de hacer ready ##")
-			Hilo := obtenerHiloMayorPrioridad()
-			go comenzarQuantum(Hilo, ConfigKernel.Quantum)
-			ejecutarInstruccion(Hilo)
-			break
-		} else if len(colaExecHilo) > 0 && tcb.Prioridad < colaExecHilo[0].Prioridad && esperarFinCompactacion {
-			enviarInterrupcion(colaExecHilo[0].Pid, colaExecHilo[0].Tid, "Prioridades")
-			break
-		}
-	}
+	planificador.notificar(Evento{Tipo: EventoHiloReady, Hilo: tcb})
 }
 
-
-func replanificar() {
-	switch ConfigKernel.AlgoritmoPlanificacion {
-	case "FIFO":
-		if len(colaReadyHilo) > 0 && len(colaExecHilo) == 0 && esperarFinCompactacion{
-			Hilo := colaReadyHilo[0]
-			ejecutarInstruccion(Hilo)
-			break
-		}
-	case "PRIORIDADES":
-		if len(colaReadyHilo) > 0 && len(colaExecHilo) == 0 && esperarFinCompactacion{
-			Hilo := obtenerHiloMayorPrioridad()
-			ejecutarInstruccion(Hilo)
-			break
-		}
-	case "CMN":
-		if len(colaReadyHilo) > 0 && len(colaExecHilo) == 0 && esperarFinCompactacion{
-			log.Printf("## Se replanifica el hilo despues de hacer exit ##")
-			Hilo := obtenerHiloMayorPrioridad()
-			go comenzarQuantum(Hilo, ConfigKernel.Quantum)
-			ejecutarInstruccion(Hilo)
-			break
-		}
-	}
-}*/
-
-
 func encolarExec(tcb TCB) {
 	mutexColaExecHilo.Lock()
 	colaExecHilo = append(colaExecHilo, tcb)
 	mutexColaExecHilo.Unlock()
+	cambiarEstadoMetricas(tcb, EstadoExec)
 
 	log.Printf("## (<PID %d>:<TID %d>) Se ejecuta el Hilo - Estado: EXEC", tcb.Pid, tcb.Tid)
 }
@@ -1122,6 +1067,9 @@ func encolarBlock(tcb TCB, motivo string) {
 	mutexColaBlockHilo.Lock()
 	colaBlockHilo = append(colaBlockHilo, tcb)
 	mutexColaBlockHilo.Unlock()
+	cambiarEstadoMetricas(tcb, EstadoBlock)
+
+	planificador.hiloBloqueado(tcb)
 
 	log.Printf("(<PID: %d >:<TID: %d >) - Bloqueado por: %s", tcb.Pid, tcb.Tid, motivo)
 }
@@ -1130,6 +1078,9 @@ func encolarExit(tcb TCB) {
 	mutexColaExitHilo.Lock()
 	colaExitHilo = append(colaExitHilo, tcb)
 	mutexColaExitHilo.Unlock()
+	cambiarEstadoMetricas(tcb, EstadoExit)
+
+	planificador.hiloFinalizado(tcb)
 
 	log.Printf(" ## (<PID: %d>:<TID: %d>) finaliza el hilo - Estado: EXIT ##", tcb.Pid, tcb.Tid)
 }
@@ -1138,14 +1089,14 @@ func quitarReady(tcb TCB) {
 	mutexColaReadyHilo.Lock()
 	colaReadyHilo = eliminarHiloCola(colaReadyHilo, tcb)
 	mutexColaReadyHilo.Unlock()
+
+	borrarIngresoReady(tcb)
 }
 
 func quitarExec(tcb TCB) {
 	mutexColaExecHilo.Lock()
 	colaExecHilo = eliminarHiloCola(colaExecHilo, tcb)
 	mutexColaExecHilo.Unlock()
-
-	//go replanificar()
 }
 
 func quitarBlock(tcb TCB) {
@@ -1155,13 +1106,12 @@ func quitarBlock(tcb TCB) {
 }
 
 func eliminarHiloCola(colaHilo []TCB, tcb TCB) []TCB {
-	var nuevaColaHilo []TCB
 	for i, t := range colaHilo {
 		if t.Pid == tcb.Pid && t.Tid == tcb.Tid {
-			nuevaColaHilo = append(colaHilo[:i], colaHilo[i+1:]...)
+			return append(colaHilo[:i], colaHilo[i+1:]...)
 		}
 	}
-	return nuevaColaHilo
+	return colaHilo // si el hilo no esta en la cola, la cola no cambia
 }
 
 func obtenerHiloDeCola(colaHilo []TCB, criterio func(TCB) bool) (TCB, error) {
@@ -1198,6 +1148,14 @@ func ManejarIo(w http.ResponseWriter, r *http.Request) {
 
 	tcb := getTCB(pid, tid)
 
+	if ioSyscall.Dispositivo != "" {
+		if !solicitarDispositivoIO(tcb, ioSyscall.Dispositivo, tiempoIO) {
+			enviarTCBCpu(tcb)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	quitarExec(tcb)
 	encolarBlock(tcb, "IO")
 
@@ -1239,7 +1197,7 @@ func DumpMemory(w http.ResponseWriter, r *http.Request) {
 		quitarBlock(tcb)
 		encolarReady(tcb)
 	} else {
-		exitProcess(pid)
+		exitProcess(pid, EstadoSalidaDumpFallido)
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -1387,98 +1345,97 @@ func LiberarMutex(w http.ResponseWriter, r *http.Request) {
 
 func lockMutex(proceso PCB, hiloSolicitante TCB, mutexNombre string) error {
 
-	for _, mutex := range proceso.Mutex { //recorro todos los mutex que hay en el proceso
-
-		if mutex.Nombre == mutexNombre {
-
-			if !mutex.Bloqueado { // si el mutex no esta bloqueado se lo asigno al hilo que lo pidio
-
-				mutex.Bloqueado = true
-				mutex.HiloUsando = hiloSolicitante.Tid
-
-				for i, m := range proceso.Mutex {
-					if m.Nombre == mutexNombre {
-						proceso.Mutex[i] = mutex
-						actualizarPCB(proceso)
-						break
-					}
-				}
-				if isInExec(hiloSolicitante) {
-
-					enviarTCBCpu(hiloSolicitante)
-				} else {
-					break
-				}
+	i, existe := buscarMutex(proceso, mutexNombre)
+	if !existe {
+		slog.Warn("El mutex no existe")
+		enviarTCBCpu(hiloSolicitante)
+		return nil
+	}
+	mutex := proceso.Mutex[i]
 
-			} else { // si el mutex esta bloqueado, encolo al hilo en la lista de bloqueados del mutex
+	if !mutex.Bloqueado { // si el mutex no esta bloqueado se lo asigno al hilo que lo pidio
 
-				mutex.colaBloqueados = append(mutex.colaBloqueados, hiloSolicitante)
-				for i, m := range proceso.Mutex {
-					if m.Nombre == mutexNombre {
-						proceso.Mutex[i] = mutex
-						actualizarPCB(proceso)
-					}
-				}
-				quitarExec(hiloSolicitante)
-				encolarBlock(hiloSolicitante, "MUTEX")
-				break
-			}
+		mutex.Bloqueado = true
+		mutex.HiloUsando = hiloSolicitante.Tid
+		proceso.Mutex[i] = mutex
+		actualizarPCB(proceso)
 
-		} else {
-			slog.Warn("El mutex no existe")
+		if isInExec(hiloSolicitante) {
 			enviarTCBCpu(hiloSolicitante)
-			break
 		}
+		return nil
 	}
+
+	// si el mutex esta bloqueado, encolo al hilo en la lista de bloqueados del mutex
+	mutex.colaBloqueados = append(mutex.colaBloqueados, hiloSolicitante)
+	proceso.Mutex[i] = mutex
+	actualizarPCB(proceso)
+
+	quitarExec(hiloSolicitante)
+	encolarBlock(hiloSolicitante, "MUTEX")
+	recalcularHerencia()
+	detectarDeadlock(hiloSolicitante)
 	return nil
 }
 
 func unlockMutex(proceso PCB, hiloSolicitante TCB, mutexNombre string) error {
 
-	for _, mutex := range proceso.Mutex {
+	i, existe := buscarMutex(proceso, mutexNombre)
+	if !existe {
+		slog.Warn("El mutex no existe")
+		return nil
+	}
+	mutex := proceso.Mutex[i]
 
-		if mutex.Nombre == mutexNombre {
+	if mutex.HiloUsando != hiloSolicitante.Tid {
+		slog.Warn("El hilo solicitante no tiene asignado al mutex")
+		return nil
+	}
 
-			if mutex.HiloUsando == hiloSolicitante.Tid {
-				mutex.Bloqueado = false
-				mutex.HiloUsando = -1
+	mutex.Bloqueado = false
+	mutex.HiloUsando = -1
 
-				if len(mutex.colaBloqueados) > 0 {
-					hiloDesbloqueado := mutex.colaBloqueados[0]
-					mutex.colaBloqueados = mutex.colaBloqueados[1:]
-					
-					for i, m := range proceso.Mutex {
-						if m.Nombre == mutexNombre {
-							proceso.Mutex[i] = mutex
-							actualizarPCB(proceso)
-							break
-						}
-					}
-					quitarBlock(hiloDesbloqueado)
-					encolarReady(hiloDesbloqueado)
-					lockMutex(proceso, hiloDesbloqueado, mutexNombre)
-					return nil 
-				}
+	if len(mutex.colaBloqueados) > 0 {
+		mutexEsperasMutex.Lock()
+		hiloDesbloqueado := mutex.colaBloqueados[0]
+		mutex.colaBloqueados = mutex.colaBloqueados[1:]
+		proceso.Mutex[i] = mutex
+		actualizarPCB(proceso)
+		espera, conTiempo := tomarEsperaMutex(hiloDesbloqueado)
+		mutexEsperasMutex.Unlock()
 
-				for i, m := range proceso.Mutex {
-					if m.Nombre == mutexNombre {
-						proceso.Mutex[i] = mutex
-						actualizarPCB(proceso)
-						break
-					}
-				}
+		// Si esperaba con MUTEX_TIMEDLOCK se le avisa que consiguio el mutex
+		if conTiempo {
+			escribirRegistro(hiloDesbloqueado, espera.registro, 1)
+		}
 
-			} else {
-				slog.Warn("El hilo solicitante no tiene asignado al mutex")
-				break
-			}
+		quitarBlock(hiloDesbloqueado)
+		encolarReady(hiloDesbloqueado)
+		lockMutex(proceso, hiloDesbloqueado, mutexNombre)
+		recalcularHerencia()
+		return nil
+	}
 
-		} else {
-			slog.Warn("El mutex no existe")
-			break
+	proceso.Mutex[i] = mutex
+	actualizarPCB(proceso)
+	recalcularHerencia()
+	return nil
+}
+
+func buscarMutex(proceso PCB, mutexNombre string) (int, bool) {
+	for i, mutex := range proceso.Mutex {
+		if mutex.Nombre == mutexNombre {
+			return i, true
 		}
 	}
-	return nil
+	return -1, false
+}
+
+// quitarDeColasDeMutex saca al hilo de las colas de bloqueados de los mutex del proceso
+func quitarDeColasDeMutex(proceso PCB, hilo TCB) {
+	for i := range proceso.Mutex {
+		proceso.Mutex[i].colaBloqueados = eliminarHiloCola(proceso.Mutex[i].colaBloqueados, hilo)
+	}
 }
 
 func mutexCreate(nombreMutex string) Mutex {
@@ -1495,13 +1452,19 @@ func mutexCreate(nombreMutex string) Mutex {
 
 func enviarInterrupcion(pid int, tid int, motivo string) {
 
+	cpu, err := getCpuDelHilo(pid, tid)
+	if err != nil {
+		slog.Warn("No se envia la interrupcion", slog.String("motivo", motivo), slog.Any("error", err))
+		return
+	}
+
 	cpuRequest := Interrupcion{}
 	cpuRequest.Pid = pid
 	cpuRequest.Tid = tid
 	cpuRequest.Interrupcion = motivo
 
-	puerto := ConfigKernel.PuertoCpu
-	ip := ConfigKernel.IpCpu
+	puerto := cpu.Puerto
+	ip := cpu.Ip
 
 	body, err := json.Marshal(&cpuRequest)
 
@@ -1539,12 +1502,24 @@ func DevolverPidTid(w http.ResponseWriter, r *http.Request) {
 	motivo := tcb.Interrupcion
 	tcbActual := getTCB(pid, tid)
 	log.Printf("## (<PID:%d>:<TID:%d>) - Desalojado por: %s ##", pid, tid, motivo)
-	quitarExec(tcbActual)
-	encolarReady(tcbActual)
+
+	if isInExec(tcbActual) {
+		registrarDesalojo(tcbActual, motivo)
+	}
+	devolverAReady(tcbActual)
 
 	w.WriteHeader(http.StatusOK)
 }
 
+func devolverAReady(tcb TCB) {
+	// Si el hilo se finalizo mientras la CPU lo ejecutaba ya no vuelve a READY
+	if !isInExec(tcb) {
+		return
+	}
+	quitarExec(tcb)
+	encolarReady(tcb)
+}
+
 func SegmentationFault(w http.ResponseWriter, r *http.Request) {
 
 	var tcb TCBRequest
@@ -1559,7 +1534,7 @@ func SegmentationFault(w http.ResponseWriter, r *http.Request) {
 	tid := tcb.Tid
 	log.Printf("## (<PID:%d>:<TID:%d>) - <SEGMENTATION_FAULT> ##", pid, tid)
 
-	exitProcess(pid)
+	exitProcess(pid, EstadoSalidaSegFault)
 
 	w.WriteHeader(http.StatusOK)
 }
\ No newline at end of file
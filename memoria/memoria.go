@@ -42,6 +42,12 @@ func main() {
 	http.HandleFunc("POST /writeMemory", utils.WriteMemoryHandler)                       //me mandan la memoria y la escribo
 	http.HandleFunc("POST /dumpMemory", utils.DumpMemory)
 	http.HandleFunc("POST /compactacion", utils.Compactacion)
+	http.HandleFunc("POST /escribirRegistro", utils.EscribirRegistro)
+	http.HandleFunc("POST /guardarContexto", utils.GuardarContexto)
+	http.HandleFunc("POST /restaurarContexto", utils.RestaurarContexto)
+	http.HandleFunc("POST /crearRegionCompartida", utils.CrearRegionCompartida)
+	http.HandleFunc("POST /adjuntarRegionCompartida", utils.AdjuntarRegionCompartida)
+	http.HandleFunc("GET /hilos", utils.ListarHilos)
 
 	http.ListenAndServe(":"+strconv.Itoa(puerto), nil)
 
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sisoputnfrba/tp-golang/memoria/globals"
+)
+
+/*-------------------- MEMORIA COMPARTIDA --------------------*/
+
+// Cada region compartida ocupa su propia particion de la memoria de usuario,
+// asignada con el mismo algoritmo que los procesos. Cada proceso ve las
+// regiones que adjunto una a continuacion de otra desde InicioMemoriaCompartida,
+// un rango de direcciones logicas reservado que la MMU de la CPU traduce a
+// (region, desplazamiento). La region se destruye, liberando su particion,
+// cuando finalizan todos los procesos que la crearon o adjuntaron.
+
+const InicioMemoriaCompartida uint32 = 0x80000000
+
+type RegionCompartida struct {
+	Nombre    string
+	particion int
+	tamanio   uint32
+	procesos  map[int]bool
+}
+
+type RegionAdjunta struct {
+	Nombre  string `json:"nombre"`
+	Base    uint32 `json:"base"` // direccion logica donde el proceso ve la region
+	Tamanio uint32 `json:"tamanio"`
+}
+
+type RegionCompartidaRequest struct {
+	Pid     int    `json:"pid"`
+	Nombre  string `json:"nombre"`
+	Tamanio int    `json:"tamanio,omitempty"`
+}
+
+var regionesCompartidas = make(map[string]*RegionCompartida)
+var adjuntosPorPID = make(map[int][]RegionAdjunta)
+
+func CrearRegionCompartida(w http.ResponseWriter, r *http.Request) {
+	var solicitud RegionCompartidaRequest
+	time.Sleep(time.Duration(MemoriaConfig.Delay_Respuesta) * time.Millisecond)
+
+	if err := json.NewDecoder(r.Body).Decode(&solicitud); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if solicitud.Tamanio <= 0 {
+		http.Error(w, "El tamaño de la region debe ser positivo", http.StatusBadRequest)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, existe := regionesCompartidas[solicitud.Nombre]; existe {
+		http.Error(w, fmt.Sprintf("La region %s ya existe", solicitud.Nombre), http.StatusConflict)
+		return
+	}
+
+	// No se pide compactar por una region, si no entra se rechaza
+	numeroDeParticion := asignarPorAlgoritmo(algoritmoBusqueda, solicitud.Tamanio)
+	if numeroDeParticion == -1 {
+		http.Error(w, fmt.Sprintf("No hay espacio en la memoria para la region %s", solicitud.Nombre), http.StatusInsufficientStorage)
+		return
+	}
+	if esquemaMemoria == "DINAMICAS" && particiones[numeroDeParticion] > solicitud.Tamanio {
+		subdividirParticion(numeroDeParticion, solicitud.Tamanio)
+		log.Printf("## Particiones: %v", particiones)
+	}
+
+	regionesCompartidas[solicitud.Nombre] = &RegionCompartida{
+		Nombre:    solicitud.Nombre,
+		particion: numeroDeParticion,
+		tamanio:   uint32(solicitud.Tamanio),
+		procesos:  map[int]bool{solicitud.Pid: true},
+	}
+
+	log.Printf("## Region Compartida Creada - Nombre: %s - Tamaño: %d - Particion: %d", solicitud.Nombre, solicitud.Tamanio, numeroDeParticion)
+	w.WriteHeader(http.StatusOK)
+}
+
+// AdjuntarRegionCompartida responde la direccion logica donde el proceso ve la region
+func AdjuntarRegionCompartida(w http.ResponseWriter, r *http.Request) {
+	var solicitud RegionCompartidaRequest
+	time.Sleep(time.Duration(MemoriaConfig.Delay_Respuesta) * time.Millisecond)
+
+	if err := json.NewDecoder(r.Body).Decode(&solicitud); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mu.Lock()
+	region, existe := regionesCompartidas[solicitud.Nombre]
+	if !existe {
+		mu.Unlock()
+		http.Error(w, fmt.Sprintf("La region %s no existe", solicitud.Nombre), http.StatusNotFound)
+		return
+	}
+
+	adjunta, yaAdjunta := buscarRegionAdjunta(solicitud.Pid, solicitud.Nombre)
+	if !yaAdjunta {
+		base := InicioMemoriaCompartida
+		for _, otra := range adjuntosPorPID[solicitud.Pid] {
+			base += otra.Tamanio
+		}
+		adjunta = RegionAdjunta{Nombre: region.Nombre, Base: base, Tamanio: region.tamanio}
+		adjuntosPorPID[solicitud.Pid] = append(adjuntosPorPID[solicitud.Pid], adjunta)
+		region.procesos[solicitud.Pid] = true
+	}
+	mu.Unlock()
+
+	log.Printf("## Region Compartida Adjuntada - PID: %d - Nombre: %s - Base: %d", solicitud.Pid, adjunta.Nombre, adjunta.Base)
+
+	respuestaJson, err := json.Marshal(adjunta)
+	if err != nil {
+		http.Error(w, "Error al codificar los datos como JSON", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(respuestaJson)
+}
+
+// buscarRegionAdjunta requiere mu tomado
+func buscarRegionAdjunta(pid int, nombre string) (RegionAdjunta, bool) {
+	for _, adjunta := range adjuntosPorPID[pid] {
+		if adjunta.Nombre == nombre {
+			return adjunta, true
+		}
+	}
+	return RegionAdjunta{}, false
+}
+
+// regionesAdjuntas requiere mu tomado, la llama GetExecutionContext
+func regionesAdjuntas(pid int) []RegionAdjunta {
+	return append([]RegionAdjunta(nil), adjuntosPorPID[pid]...)
+}
+
+// accederRegionCompartida valida que el proceso tenga adjuntada la region y que
+// los 4 bytes desde el desplazamiento esten dentro de ella. Requiere mu tomado.
+func accederRegionCompartida(pid int, nombre string, desplazamiento uint32) ([]byte, error) {
+	if _, adjunta := buscarRegionAdjunta(pid, nombre); !adjunta {
+		return nil, fmt.Errorf("el PID %d no tiene adjuntada la region %s", pid, nombre)
+	}
+	region := regionesCompartidas[nombre]
+	if uint64(desplazamiento)+4 > uint64(region.tamanio) {
+		return nil, fmt.Errorf("dirección fuera de rango de la region %s", nombre)
+	}
+	base := uint32(sumatoria(region.particion)) + desplazamiento
+	return globals.MemoriaUsuario[base : base+4], nil
+}
+
+// datosRegionesAdjuntas devuelve una copia del contenido de las regiones que adjunto el proceso, en el orden en que las ve
+func datosRegionesAdjuntas(pid int) []byte {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var datos []byte
+	for _, adjunta := range adjuntosPorPID[pid] {
+		base := uint32(sumatoria(regionesCompartidas[adjunta.Nombre].particion))
+		datos = append(datos, globals.MemoriaUsuario[base:base+adjunta.Tamanio]...)
+	}
+	return datos
+}
+
+// actualizarParticionesDeRegiones corre las regiones igual que actualizarPCBxParticionNueva corre a los procesos
+func actualizarParticionesDeRegiones(numeroPart int) {
+	for _, region := range regionesCompartidas {
+		if region.particion >= numeroPart {
+			region.particion--
+		}
+	}
+}
+
+func ReadRegionCompartida(pid int, nombre string, desplazamiento uint32) ([]byte, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	datos, err := accederRegionCompartida(pid, nombre, desplazamiento)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), datos...), nil
+}
+
+func WriteRegionCompartida(pid int, nombre string, desplazamiento uint32, data []byte) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	datos, err := accederRegionCompartida(pid, nombre, desplazamiento)
+	if err != nil {
+		return err
+	}
+	copy(datos, data)
+	return nil
+}
+
+// desadjuntarRegiones se llama al finalizar el proceso y destruye las regiones que quedan sin procesos
+func desadjuntarRegiones(pid int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(adjuntosPorPID, pid)
+	for nombre, region := range regionesCompartidas {
+		delete(region.procesos, pid)
+		if len(region.procesos) == 0 {
+			delete(regionesCompartidas, nombre)
+			mapParticiones[region.particion] = false
+			if esquemaMemoria == "DINAMICAS" {
+				consolidarParticiones(region.particion)
+			}
+			log.Printf("## Region Compartida Destruida - Nombre: %s - Tamaño: %d", nombre, region.tamanio)
+		}
+	}
+}
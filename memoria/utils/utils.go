@@ -130,10 +130,11 @@ type Req struct {
 type MemoryRequest struct {
 	PID     int    `json:"pid"`
 	TID     int    `json:"tid,omitempty"`
-	Address uint32 `json:"address"`        //direccion de memoria a leer
-	Size    int    `json:"size,omitempty"` //tamaño de la memoria a leer
-	Data    []byte `json:"data,omitempty"` //datos a escribir o leer y los devuelvo
-	Port    int    `json:"port,omitempty"` //puerto
+	Address uint32 `json:"address"`          //direccion de memoria a leer
+	Size    int    `json:"size,omitempty"`   //tamaño de la memoria a leer
+	Data    []byte `json:"data,omitempty"`   //datos a escribir o leer y los devuelvo
+	Port    int    `json:"port,omitempty"`   //puerto
+	Region  string `json:"region,omitempty"` //region compartida, Address es el desplazamiento dentro de ella
 }
 
 type TCBRequest struct {
@@ -147,8 +148,9 @@ type InstructionResponse struct {
 }
 
 type GetExecutionContextResponse struct {
-	Pcb PCB            `json:"pcb"`
-	Tcb estructuraHilo `json:"tcb"`
+	Pcb         PCB             `json:"pcb"`
+	Tcb         estructuraHilo  `json:"tcb"`
+	Compartidas []RegionAdjunta `json:"compartidas,omitempty"` // regiones compartidas adjuntadas por el proceso
 }
 
 /*-------------------- VAR GLOBALES --------------------*/
@@ -324,6 +326,9 @@ func GetExecutionContext(w http.ResponseWriter, r *http.Request) {
 
 	time.Sleep(time.Duration(MemoriaConfig.Delay_Respuesta) * time.Millisecond)
 
+	mu.Lock()
+	defer mu.Unlock()
+
 	// Usar la función `buscarTCBPorPid` para obtener el tidMap
 	tidMap := buscarTCBPorPid(solicitud.Pid)
 	if tidMap == nil {
@@ -343,6 +348,7 @@ func GetExecutionContext(w http.ResponseWriter, r *http.Request) {
 			respuesta.Pcb.Base = valores.Base
 			respuesta.Pcb.Limit = valores.Limit
 			respuesta.Tcb = tcb
+			respuesta.Compartidas = regionesAdjuntas(solicitud.Pid)
 
 			// Codificar la respuesta como JSON
 			respuestaJson, err := json.Marshal(respuesta)
@@ -377,6 +383,9 @@ func UpdateExecutionContext(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mu.Lock()
+	defer mu.Unlock()
+
 	// Usar la función `buscarTCBPorPid` para obtener el tidMap
 	tidMap := buscarTCBPorPid(actualizadoContexto.Pcb.Pid)
 	if tidMap == nil {
@@ -426,6 +435,191 @@ func ModificarValores(pid int, base uint32, limit uint32) {
 	mapPIDxBaseLimit[pid] = valor
 }
 
+//-----------------------------ESCRIBIR REGISTRO-------------------------------------
+// El kernel devuelve el resultado de algunas syscalls en un registro del hilo
+
+type RegistroRequest struct {
+	Pid      int    `json:"pid"`
+	Tid      int    `json:"tid"`
+	Registro string `json:"registro"`
+	Valor    uint32 `json:"valor"`
+}
+
+func EscribirRegistro(w http.ResponseWriter, r *http.Request) {
+	var solicitud RegistroRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&solicitud); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	pcb, err := obtenerPCBPorPID(solicitud.Pid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	for tcb := range mapPCBPorTCB[pcb] {
+		if tcb.Tid != solicitud.Tid {
+			continue
+		}
+		nuevoTCB := tcb
+		if err := asignarRegistro(&nuevoTCB, solicitud.Registro, solicitud.Valor); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ModificarContexto(pcb, tcb, nuevoTCB)
+
+		log.Printf("## Registro Escrito - (PID:TID) - (%d:%d) - %s: %d", solicitud.Pid, solicitud.Tid, solicitud.Registro, solicitud.Valor)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	http.Error(w, "TID no ha sido encontrado", http.StatusNotFound)
+}
+
+func asignarRegistro(tcb *estructuraHilo, registro string, valor uint32) error {
+	switch registro {
+	case "AX":
+		tcb.AX = valor
+	case "BX":
+		tcb.BX = valor
+	case "CX":
+		tcb.CX = valor
+	case "DX":
+		tcb.DX = valor
+	case "EX":
+		tcb.EX = valor
+	case "FX":
+		tcb.FX = valor
+	case "GX":
+		tcb.GX = valor
+	case "HX":
+		tcb.HX = valor
+	default:
+		return fmt.Errorf("registro %s no valido", registro)
+	}
+	return nil
+}
+
+//-----------------------------GUARDAR Y RESTAURAR CONTEXTO-------------------------------------
+// Antes de saltar al manejador de una señal la CPU guarda el contexto del hilo,
+// y SIGNAL_RETURN lo restaura. Se apilan por si llega otra señal dentro del manejador.
+
+var contextosGuardados = make(map[Req][]estructuraHilo)
+
+func GuardarContexto(w http.ResponseWriter, r *http.Request) {
+	var solicitud Req
+
+	if err := json.NewDecoder(r.Body).Decode(&solicitud); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	pcb, err := obtenerPCBPorPID(solicitud.Pid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	for tcb := range mapPCBPorTCB[pcb] {
+		if tcb.Tid != solicitud.Tid {
+			continue
+		}
+		contextosGuardados[solicitud] = append(contextosGuardados[solicitud], tcb)
+
+		log.Printf("## Contexto Guardado - (PID:TID) - (%d:%d)", solicitud.Pid, solicitud.Tid)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	http.Error(w, "TID no ha sido encontrado", http.StatusNotFound)
+}
+
+func RestaurarContexto(w http.ResponseWriter, r *http.Request) {
+	var solicitud Req
+
+	if err := json.NewDecoder(r.Body).Decode(&solicitud); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	guardados := contextosGuardados[solicitud]
+	if len(guardados) == 0 {
+		http.Error(w, "El hilo no tiene contextos guardados", http.StatusConflict)
+		return
+	}
+
+	pcb, err := obtenerPCBPorPID(solicitud.Pid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	for tcb := range mapPCBPorTCB[pcb] {
+		if tcb.Tid != solicitud.Tid {
+			continue
+		}
+		ModificarContexto(pcb, tcb, guardados[len(guardados)-1])
+		contextosGuardados[solicitud] = guardados[:len(guardados)-1]
+
+		log.Printf("## Contexto Restaurado - (PID:TID) - (%d:%d)", solicitud.Pid, solicitud.Tid)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	http.Error(w, "TID no ha sido encontrado", http.StatusNotFound)
+}
+
+// olvidarContextosGuardados descarta los contextos de los hilos del proceso que finaliza
+func olvidarContextosGuardados(pid int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for hilo := range contextosGuardados {
+		if hilo.Pid == pid {
+			delete(contextosGuardados, hilo)
+		}
+	}
+}
+
+//-----------------------------HILOS VIVOS-------------------------------------
+
+type HilosVivosResponse struct {
+	Procesos []int `json:"procesos"`
+	Hilos    []Req `json:"hilos"`
+}
+
+// ListarHilos devuelve los PID y TID que memoria tiene cargados, el kernel los usa para reconciliar al restaurar un checkpoint
+func ListarHilos(w http.ResponseWriter, r *http.Request) {
+	respuesta := HilosVivosResponse{Procesos: []int{}, Hilos: []Req{}}
+	mu.Lock()
+	for pcb, tidMap := range mapPCBPorTCB {
+		respuesta.Procesos = append(respuesta.Procesos, pcb.Pid)
+		for tcb := range tidMap {
+			respuesta.Hilos = append(respuesta.Hilos, Req{Pid: pcb.Pid, Tid: tcb.Tid})
+		}
+	}
+	mu.Unlock()
+
+	respuestaJson, err := json.Marshal(respuesta)
+	if err != nil {
+		http.Error(w, "Error al codificar los datos como JSON", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(respuestaJson)
+}
+
 //-----------------------------------------CREATE PROCESS-------------------------------------------
 
 func CreateProcess(w http.ResponseWriter, r *http.Request) { //recibe la pid y el size
@@ -612,6 +806,7 @@ func actualizarPCBxParticionNueva(numeroPart int) {
 			cambiarBaseYLimite(pid, particion)
 		}
 	}
+	actualizarParticionesDeRegiones(numeroPart)
 	log.Printf("MAP FINAL QUERIENDOSE ACTUALIZAR: %v", mapPCBPorParticion)
 }
 
@@ -842,6 +1037,7 @@ func TerminateProcess(w http.ResponseWriter, r *http.Request) {
 	}
 
 	tamanio := particiones[numeroDeParticion]
+	olvidarContextosGuardados(pid)
 
 	if esquemaMemoria == "FIJAS" { //PARA FIJAS
 		mapParticiones[numeroDeParticion] = false // libero el map booleano que indicaba si la particion esta libre o no
@@ -855,6 +1051,8 @@ func TerminateProcess(w http.ResponseWriter, r *http.Request) {
 		delete(mapPCBPorTCB, PCB{Pid: pid})
 		delete(mapPIDxBaseLimit, pid)
 	}
+	desadjuntarRegiones(pid)
+
 	// Log de destrucción de proceso
 	log.Printf("## Proceso Destruido - PID: %d - Tamaño: %d", pid, tamanio)
 	w.WriteHeader(http.StatusOK)
@@ -1021,12 +1219,16 @@ func TerminateThread(w http.ResponseWriter, r *http.Request) {
 		return
 	}*/
 
+	mu.Lock()
+	delete(contextosGuardados, req)
+
 	if tcbMap, found := mapPCBPorTCB[PCB{Pid: req.Pid}]; found {
 		delete(tcbMap, estructuraHilo{Pid: req.Pid, Tid: req.Tid})
 		if len(tcbMap) == 0 {
 			delete(mapPCBPorTCB, PCB{Pid: req.Pid}) //por si llega a quedar vacio
 		}
 	}
+	mu.Unlock()
 
 	// Log de destrucción de hilo
 	log.Printf("## Hilo Destruido - (PID:TID) - (%d:%d)", req.Pid, req.Tid)
@@ -1046,7 +1248,13 @@ func ReadMemoryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := ReadMemory(memReq.PID, memReq.TID, memReq.Address)
+	var data []byte
+	var err error
+	if memReq.Region != "" {
+		data, err = ReadRegionCompartida(memReq.PID, memReq.Region, memReq.Address)
+	} else {
+		data, err = ReadMemory(memReq.PID, memReq.TID, memReq.Address)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1152,6 +1360,14 @@ func WriteMemoryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if memReq.Region != "" {
+		if err := WriteRegionCompartida(memReq.PID, memReq.Region, memReq.Address, memReq.Data); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 	if err := WriteMemory(memReq.PID, memReq.TID, memReq.Address, memReq.Data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -1250,9 +1466,12 @@ func DumpMemory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Leer datos de memoria
-	data := globals.MemoriaUsuario[valor.Base:valor.Limit]
+	// Leer datos de memoria, seguidos de las regiones compartidas que adjunto el proceso
+	data := append([]byte(nil), globals.MemoriaUsuario[valor.Base:valor.Limit]...)
 	tamanio := valor.Limit - valor.Base + 1
+	compartidas := datosRegionesAdjuntas(tcbReq.Pid)
+	data = append(data, compartidas...)
+	tamanio += uint32(len(compartidas))
 
 	informacion := FsInfo{
 		Data:          data,